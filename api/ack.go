@@ -0,0 +1,91 @@
+package api
+
+import (
+	"github.com/ZeljkoBenovic/go-notify/notify/incident"
+	"html/template"
+	"net/http"
+	"strings"
+)
+
+// ackPage asks for the name of the person taking the incident over, so opening the link alone,
+// for example by a chat client rendering a preview, does not acknowledge the incident
+var ackPage = template.Must(template.New("ack").Parse(`<!doctype html>
+<html>
+<head>
+    <meta name="viewport" content="width=device-width, initial-scale=1.0"/>
+    <title>go-notify incident</title>
+</head>
+<body style="font-family: sans-serif">
+{{ if .Error }}
+    <p><strong>{{ .Error }}</strong></p>
+{{ end }}
+{{ with .Incident }}
+    <p>Incident of <strong>{{ .Endpoint }}</strong> ({{ .Url }}), open since {{ .Since.Format "2006-01-02 15:04:05 MST" }}</p>
+    {{ if .Acknowledged }}
+        <p>Acknowledged by <strong>{{ .AcknowledgedBy }}</strong> at {{ .AcknowledgedAt.Format "2006-01-02 15:04:05 MST" }}</p>
+    {{ else }}
+        <form method="post">
+            <input type="hidden" name="sig" value="{{ $.Signature }}">
+            <label>Your name <input type="text" name="by" required></label>
+            <button type="submit">Acknowledge</button>
+        </form>
+    {{ end }}
+{{ end }}
+</body>
+</html>
+`))
+
+type ackPageData struct {
+	Incident  *incident.Incident
+	Signature string
+	Error     string
+}
+
+// handleAckLink shows the incident of a signed acknowledge link, and acknowledges it once the form is submitted
+func (s *Server) handleAckLink(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/ack/")
+	data := ackPageData{Signature: r.FormValue("sig")}
+
+	if s.links == nil || !s.links.Verify(id, data.Signature) {
+		data.Error = "invalid acknowledge link"
+		renderAckPage(w, http.StatusForbidden, data)
+		return
+	}
+
+	found, err := s.registry.Find(id)
+	if err != nil {
+		data.Error = "the incident is already resolved"
+		renderAckPage(w, statusOf(err), data)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		by := strings.TrimSpace(r.FormValue("by"))
+		if by == "" {
+			data.Error = "name is required"
+			break
+		}
+
+		found, err = s.acknowledge(id, by)
+		if err != nil {
+			data.Error = "the incident is already resolved"
+			renderAckPage(w, statusOf(err), data)
+			return
+		}
+	default:
+		data.Error = "method not allowed"
+		renderAckPage(w, http.StatusMethodNotAllowed, data)
+		return
+	}
+
+	data.Incident = &found
+	renderAckPage(w, http.StatusOK, data)
+}
+
+func renderAckPage(w http.ResponseWriter, status int, data ackPageData) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	_ = ackPage.Execute(w, data)
+}
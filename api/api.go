@@ -0,0 +1,159 @@
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"github.com/ZeljkoBenovic/go-notify/config"
+	"github.com/ZeljkoBenovic/go-notify/notify/incident"
+	"github.com/ZeljkoBenovic/go-notify/notify/silence"
+	"github.com/hashicorp/go-hclog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Server is the embedded HTTP server used to acknowledge incidents, through the signed links
+// in the notifications or through the JSON API, and to manage the silences
+type Server struct {
+	registry *incident.Registry
+	silences *silence.Registry
+	links    *incident.Links
+	token    string
+
+	server *http.Server
+	logger hclog.Logger
+}
+
+// NewServer creates the server for the incidents and silences of the registries
+func NewServer(registry *incident.Registry, silences *silence.Registry, config *config.Config) *Server {
+	s := &Server{
+		registry: registry,
+		silences: silences,
+		links:    incident.NewLinks(config),
+		token:    config.Api.Token,
+		logger:   config.Logger.Named("api"),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ack/", s.handleAckLink)
+	mux.HandleFunc("/api/incidents", s.authorized(s.handleIncidents))
+	mux.HandleFunc("/api/incidents/", s.authorized(s.handleIncident))
+	mux.HandleFunc("/api/silences", s.authorized(s.handleSilences))
+	mux.HandleFunc("/api/silences/", s.authorized(s.handleSilence))
+
+	s.server = &http.Server{
+		Addr:              config.Api.Listen,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	return s
+}
+
+// ListenAndServe runs the server until it fails
+func (s *Server) ListenAndServe() error {
+	s.logger.Info("api server listening", "address", s.server.Addr)
+
+	return s.server.ListenAndServe()
+}
+
+// handleIncidents lists all open incidents
+func (s *Server) handleIncidents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.registry.List())
+}
+
+// handleIncident returns a single open incident, or acknowledges it on /api/incidents/{id}/ack
+func (s *Server) handleIncident(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/incidents/")
+
+	if id := strings.TrimSuffix(path, "/ack"); id != path {
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+
+		var req struct {
+			By string `json:"by"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "could not decode request: "+err.Error())
+			return
+		}
+
+		if strings.TrimSpace(req.By) == "" {
+			writeError(w, http.StatusBadRequest, "by is required")
+			return
+		}
+
+		ack, err := s.acknowledge(id, req.By)
+		if err != nil {
+			writeError(w, statusOf(err), err.Error())
+			return
+		}
+
+		writeJSON(w, http.StatusOK, ack)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	found, err := s.registry.Find(path)
+	if err != nil {
+		writeError(w, statusOf(err), err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, found)
+}
+
+// acknowledge marks the incident as taken over and logs who did it
+func (s *Server) acknowledge(id, by string) (incident.Incident, error) {
+	ack, err := s.registry.Acknowledge(id, strings.TrimSpace(by))
+	if err != nil {
+		return incident.Incident{}, err
+	}
+
+	s.logger.Info("incident acknowledged", "incident", ack.ID, "endpoint", ack.Endpoint, "by", ack.AcknowledgedBy)
+
+	return ack, nil
+}
+
+// authorized rejects the requests without the api token
+func (s *Server) authorized(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			writeError(w, http.StatusUnauthorized, "invalid api token")
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+func statusOf(err error) int {
+	if errors.Is(err, incident.ErrNotFound) || errors.Is(err, silence.ErrNotFound) {
+		return http.StatusNotFound
+	}
+
+	return http.StatusInternalServerError
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/ZeljkoBenovic/go-notify/notify/silence"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Client talks to the JSON API of a running go-notify
+type Client struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+func NewClient(url, token string) *Client {
+	return &Client{
+		url:    strings.TrimSuffix(url, "/"),
+		token:  token,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// AddSilence creates a new silence
+func (c *Client) AddSilence(req SilenceRequest) (silence.Silence, error) {
+	var created silence.Silence
+	err := c.do(http.MethodPost, "/api/silences", req, &created)
+
+	return created, err
+}
+
+// ListSilences returns the active silences
+func (c *Client) ListSilences() ([]silence.Silence, error) {
+	var silences []silence.Silence
+	err := c.do(http.MethodGet, "/api/silences", nil, &silences)
+
+	return silences, err
+}
+
+// RemoveSilence expires the silence with the provided ID
+func (c *Client) RemoveSilence(id string) error {
+	return c.do(http.MethodDelete, "/api/silences/"+id, nil, nil)
+}
+
+func (c *Client) do(method, path string, body, out interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		buff, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("could not encode request: %w", err)
+		}
+		reqBody = bytes.NewReader(buff)
+	}
+
+	req, err := http.NewRequest(method, c.url+path, reqBody)
+	if err != nil {
+		return fmt.Errorf("could not create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("could not reach api: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&apiErr)
+
+		return fmt.Errorf("api returned %s: %s", resp.Status, apiErr.Error)
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("could not decode response: %w", err)
+	}
+
+	return nil
+}
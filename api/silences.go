@@ -0,0 +1,67 @@
+package api
+
+import (
+	"encoding/json"
+	"github.com/ZeljkoBenovic/go-notify/config"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// SilenceRequest is the body used to create a silence
+type SilenceRequest struct {
+	Match config.Matcher `json:"match"`
+	// Duration is how long the silence lasts, as a duration like 2h or a number of seconds
+	Duration  config.Duration `json:"duration"`
+	CreatedBy string          `json:"created_by"`
+	Comment   string          `json:"comment,omitempty"`
+}
+
+// handleSilences lists the active silences, or creates a new one
+func (s *Server) handleSilences(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.silences.List())
+	case http.MethodPost:
+		var req SilenceRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "could not decode request: "+err.Error())
+			return
+		}
+
+		if strings.TrimSpace(req.CreatedBy) == "" {
+			writeError(w, http.StatusBadRequest, "created_by is required")
+			return
+		}
+
+		created, err := s.silences.Add(req.Match, time.Duration(req.Duration), req.CreatedBy, req.Comment)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		s.logger.Info("silence created", "silence", created.ID, "by", created.CreatedBy, "ends_at", created.EndsAt)
+
+		writeJSON(w, http.StatusCreated, created)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// handleSilence removes the silence on /api/silences/{id}
+func (s *Server) handleSilence(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/api/silences/")
+	if err := s.silences.Remove(id); err != nil {
+		writeError(w, statusOf(err), err.Error())
+		return
+	}
+
+	s.logger.Info("silence removed", "silence", id)
+
+	w.WriteHeader(http.StatusNoContent)
+}
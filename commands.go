@@ -0,0 +1,289 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/ZeljkoBenovic/go-notify/config"
+	"github.com/ZeljkoBenovic/go-notify/monitor"
+	"github.com/ZeljkoBenovic/go-notify/notify"
+	"github.com/ZeljkoBenovic/go-notify/notify/common"
+	"github.com/ZeljkoBenovic/go-notify/notify/incident"
+	"github.com/ZeljkoBenovic/go-notify/notify/silence"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// version is set at build time with -ldflags "-X main.version=<version>"
+var version = "dev"
+
+// errUnhealthy is returned by the check command if any endpoint is down
+var errUnhealthy = errors.New("not all endpoints are healthy")
+
+// command is a subcommand of go-notify
+type command struct {
+	name    string
+	usage   string
+	summary string
+	help    string
+	run     func(flags *flag.FlagSet, args []string) error
+}
+
+var commands = []command{
+	{
+		name:    "run",
+		usage:   "run [flags]",
+		summary: "Run the health checks on every interval and send the notifications",
+		help: "Runs the health checks on every interval and sends the notifications, until the process is stopped.\n" +
+			"The config file is reloaded on SIGHUP and whenever it changes.\n\n" +
+			"Every setting flag can also be set with a GONOTIFY_ environment variable named after it,\n" +
+			"e.g. GONOTIFY_SMTP_SERVER for -smtp-server. The config file settings are replaced by the environment\n" +
+			"variables, which are replaced by the flags set on the command line. The notification service flags\n" +
+			"replace the settings of every notification_services entry of their type.",
+		run: runDaemon,
+	},
+	{
+		name:    "check",
+		usage:   "check [flags]",
+		summary: "Run the health checks once and exit with their result",
+		help: "Runs the health checks once and prints the state of every endpoint.\n" +
+			"Exits with 0 if all endpoints are healthy, 2 if any of them is down and 1 on any other error.",
+		run: runCheck,
+	},
+	{
+		name:    "test-notify",
+		usage:   "test-notify [flags] <notification service>",
+		summary: "Send a sample alert through a notification service",
+		help: "Sends a sample alert straight to the notification service, the name of a notification_services entry,\n" +
+			"to check that its settings work. Reminders, digest, notification policy and retries are not applied.",
+		run: runTestNotify,
+	},
+	{
+		name:    "config",
+		usage:   "config init [flags] | show [flags] | schema",
+		summary: "Write an example config file, show the effective configuration or print its schema",
+		help: "init writes a config file with the default settings of every notification service, annotated with comments.\n" +
+			"show prints the configuration loaded like the run command, with the secrets redacted,\n" +
+			"and where every setting came from: default, file, env or flag.\n" +
+			"schema prints the JSON Schema of the config file, for editors and CI.",
+		run: runConfig,
+	},
+	{
+		name:    "validate",
+		usage:   "validate [flags]",
+		summary: "Check the configuration and report every problem",
+		help: "Loads the configuration like the run command and reports every problem with its path and line in the config file.\n" +
+			"Exits with 1 if any problem is found.",
+		run: runValidate,
+	},
+	{
+		name:    "silence",
+		usage:   "silence add [flags] | list [flags] | remove [flags] <id>",
+		summary: "Manage the silences of a running go-notify",
+		help:    "Adds, lists and removes the silences of a running go-notify through its api.",
+		run:     runSilence,
+	},
+	{
+		name:    "version",
+		usage:   "version",
+		summary: "Print the version",
+		help:    "Prints the version of go-notify and the Go version it was built with.",
+		run:     runVersion,
+	},
+}
+
+// findCommand returns the command with the name
+func findCommand(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+
+	return command{}, false
+}
+
+// flagSet returns the flag set of the command, printing the help of the command as its usage
+func (c command) flagSet() *flag.FlagSet {
+	flags := flag.NewFlagSet(c.name, flag.ContinueOnError)
+
+	flags.Usage = func() {
+		out := flags.Output()
+		fmt.Fprintf(out, "Usage: go-notify %s\n\n%s\n", c.usage, c.help)
+
+		hasFlags := false
+		flags.VisitAll(func(*flag.Flag) { hasFlags = true })
+		if hasFlags {
+			fmt.Fprintln(out, "\nFlags:")
+			flags.PrintDefaults()
+		}
+	}
+
+	return flags
+}
+
+// usage prints the available commands
+func usage() {
+	out := os.Stderr
+
+	fmt.Fprintln(out, "Usage: go-notify <command> [flags]")
+	fmt.Fprintln(out, "\nCommands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(out, "  %-12s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(out, "\nRun \"go-notify help <command>\" for the help and flags of a command.")
+	fmt.Fprintln(out, "Without a command, go-notify runs the run command with the flags.")
+}
+
+// runCheck runs the health checks once, printing the state of every endpoint
+func runCheck(flags *flag.FlagSet, args []string) error {
+	send := flags.Bool("send", false, "Send the notifications about the failing endpoints, the digest is not applied")
+
+	conf, err := config.Load(flags, args)
+	if err != nil {
+		return err
+	}
+
+	// the notification services only have to be set up if the notifications are sent
+	check := conf.CheckMonitors
+	if *send {
+		check = conf.Check
+	}
+	if err := check(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	mon, err := monitor.NewMonitor(conf)
+	if err != nil {
+		return fmt.Errorf("could not set up monitor: %w", err)
+	}
+
+	if *send {
+		conf.DisableDigest()
+
+		notifier, err := notify.NewNotifier(conf, incident.NewRegistry(), silence.NewRegistry())
+		if err != nil {
+			return fmt.Errorf("could not set up notifier service: %w", err)
+		}
+
+		mon.SetNotifier(notifier)
+		mon.Run()
+
+		// the notifications are delivered in the background
+		notifier.Close()
+	} else {
+		mon.Check()
+	}
+
+	healthy := true
+	for _, ep := range mon.Status() {
+		if ep.Status == common.StatusUp {
+			fmt.Printf("%-4s %s %s\n", ep.Status, ep.Name, ep.Url)
+			continue
+		}
+
+		healthy = false
+		fmt.Printf("%-4s %s %s: %s\n", ep.Status, ep.Name, ep.Url, ep.Error)
+	}
+
+	if !healthy {
+		return errUnhealthy
+	}
+
+	return nil
+}
+
+// runTestNotify sends a sample alert through the notification service given as the argument
+func runTestNotify(flags *flag.FlagSet, args []string) error {
+	// the name can be given before or after the flags
+	var name string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+
+	conf, err := config.NewConfig(flags, args)
+	if err != nil {
+		return err
+	}
+
+	if name == "" && flags.NArg() > 0 {
+		name = flags.Arg(0)
+	}
+
+	if name == "" {
+		flags.Usage()
+		return fmt.Errorf("notification service not provided")
+	}
+
+	if err := notify.SendTest(conf, name); err != nil {
+		return fmt.Errorf("could not send test notification: %w", err)
+	}
+
+	fmt.Printf("test notification sent to %s\n", name)
+
+	return nil
+}
+
+// runConfig manages the config file: config init|show|schema
+func runConfig(flags *flag.FlagSet, args []string) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		if err := flags.Parse(args); err != nil {
+			return err
+		}
+		flags.Usage()
+		return fmt.Errorf("config command not provided")
+	}
+
+	action, args := args[0], args[1:]
+
+	switch action {
+	case "init":
+		output := flags.String("output", "config.yaml", "Location of the generated config file")
+		force := flags.Bool("force", false, "Replace the config file if it already exists")
+
+		if err := flags.Parse(args); err != nil {
+			return err
+		}
+
+		if err := config.WriteExample(*output, *force); err != nil {
+			return fmt.Errorf("could not create default config file: %w", err)
+		}
+
+		fmt.Printf("config file %s successfully generated\n", *output)
+	case "schema":
+		if err := flags.Parse(args); err != nil {
+			return err
+		}
+
+		schema, err := config.Schema()
+		if err != nil {
+			return fmt.Errorf("could not generate config schema: %w", err)
+		}
+
+		fmt.Println(string(schema))
+	case "show":
+		conf, err := config.Load(flags, args)
+		if err != nil {
+			return err
+		}
+
+		return conf.Show(os.Stdout)
+	default:
+		flags.Usage()
+		return fmt.Errorf("unknown config command %s", action)
+	}
+
+	return nil
+}
+
+// runVersion prints the version
+func runVersion(flags *flag.FlagSet, args []string) error {
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	fmt.Printf("go-notify %s (%s %s/%s)\n", version, runtime.Version(), runtime.GOOS, runtime.GOARCH)
+
+	return nil
+}
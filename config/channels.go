@@ -0,0 +1,157 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"gopkg.in/yaml.v3"
+)
+
+// notifierTypes are the types a channel instance can have
+var notifierTypes = []string{"email", "slack", "matrix", "ntfy", "gotify", "sms", "exec", "syslog"}
+
+// ChannelInstance is a named notification service with its own settings. Its type is
+// set with the type key, or taken from the name of the instance if the key is missing.
+type ChannelInstance struct {
+	Type string
+	// NotificationServices holds the settings of the instance, only the ones of its type are used
+	NotificationServices
+
+	// node is kept until the instance is resolved, so its settings are applied over the defaults of its type
+	node *yaml.Node
+}
+
+// Settings returns the common settings of the instance
+func (c *ChannelInstance) Settings() Channel {
+	return c.NotificationServices.Channel(c.Type)
+}
+
+func (c *ChannelInstance) UnmarshalYAML(node *yaml.Node) error {
+	var header struct {
+		Type string `yaml:"type"`
+	}
+	if err := node.Decode(&header); err != nil {
+		return err
+	}
+
+	c.Type = header.Type
+	c.node = node
+
+	return nil
+}
+
+func (c *ChannelInstance) MarshalYAML() (interface{}, error) {
+	node := &yaml.Node{}
+	if err := node.Encode(c.NotificationServices.settings(c.Type)); err != nil {
+		return nil, err
+	}
+
+	typeNodes := []*yaml.Node{
+		{Kind: yaml.ScalarNode, Value: "type"},
+		{Kind: yaml.ScalarNode, Value: c.Type},
+	}
+	node.Content = append(typeNodes, node.Content...)
+
+	return node, nil
+}
+
+// resolveChannels applies the settings of every channel instance over the defaults of its type.
+// Without notification services in the config file, the flags configure a single channel named after the notify service.
+func (f *Config) resolveChannels() error {
+	// a notify service which is not a type is reported by the validation where it is set
+	if len(f.Channels) == 0 && (&NotificationServices{}).settings(f.NotifyService) != nil {
+		f.Channels = map[string]*ChannelInstance{
+			f.NotifyService: {},
+		}
+	}
+
+	for name, channel := range f.Channels {
+		// an instance without any settings is decoded as nil
+		if channel == nil {
+			channel = &ChannelInstance{}
+			f.Channels[name] = channel
+		}
+
+		if channel.Type == "" {
+			channel.Type = name
+		}
+
+		// the unknown types are reported by the validation
+		services := f.Services
+		settings := services.settings(channel.Type)
+
+		// the digest of the config is the default of every instance
+		if common := services.channel(channel.Type); common != nil {
+			common.Digest = f.Digest
+		}
+
+		if channel.node != nil && settings != nil {
+			if err := channel.node.Decode(settings); err != nil {
+				typeErr, ok := err.(*yaml.TypeError)
+				if !ok {
+					return fmt.Errorf("could not decode notification service %s: %w", name, err)
+				}
+				f.addTypeError(typeErr)
+			}
+		}
+		channel.node = nil
+
+		// the environment variables and the explicitly set flags replace the settings of every instance
+		overrides := flag.NewFlagSet(name, flag.ContinueOnError)
+		services.registerFlags(overrides)
+		if err := f.applyOverrides(overrides); err != nil {
+			return err
+		}
+
+		// the smtp user defaults to the sender of the emails
+		if channel.Type == "email" && services.Email.UseAuth && services.Email.AuthUser == "" {
+			services.Email.AuthUser = services.Email.From
+		}
+
+		channel.NotificationServices = services
+	}
+
+	return nil
+}
+
+// DisableDigest turns off the digest of the config and of every channel instance
+func (f *Config) DisableDigest() {
+	f.Digest.Window = 0
+
+	for _, channel := range f.Channels {
+		if common := channel.NotificationServices.channel(channel.Type); common != nil {
+			common.Digest.Window = 0
+		}
+	}
+}
+
+// usedChannels returns the names of the channel instances used by the notify service, escalation policies and routes,
+// leaving out the routes with their own recipients, as they do not need the recipients of the instance
+func (f *Config) usedChannels() []string {
+	names := []string{f.NotifyService}
+
+	for _, tiers := range f.EscalationPolicies {
+		for _, tier := range tiers {
+			names = append(names, tier.Notify...)
+		}
+	}
+
+	for _, route := range f.Routes {
+		if len(route.To) == 0 {
+			names = append(names, route.Channels...)
+		}
+	}
+
+	return names
+}
+
+// withDefaultChannels adds an instance with the default settings of every notifier type, used for the generated config file
+func (f *Config) withDefaultChannels() {
+	f.Channels = map[string]*ChannelInstance{}
+
+	for _, notifierType := range notifierTypes {
+		f.Channels[notifierType] = &ChannelInstance{
+			Type:                 notifierType,
+			NotificationServices: f.Services,
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package config
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveEmailAuthUser(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings string
+		want     string
+	}{
+		{name: "sender", settings: "use_auth: true\n    from: alerts@example.com", want: "alerts@example.com"},
+		{name: "set user", settings: "use_auth: true\n    from: alerts@example.com\n    auth_user: smtp", want: "smtp"},
+		{name: "without auth", settings: "use_auth: false\n    from: alerts@example.com", want: ""},
+	}
+
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		content := "notify_service: mail\nnotification_services:\n  mail:\n    type: email\n    " + tt.settings + "\n"
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		flags := flag.NewFlagSet("test", flag.ContinueOnError)
+		flags.SetOutput(io.Discard)
+
+		f, err := Load(flags, []string{"-config", path})
+		if err != nil {
+			t.Errorf("%s: Load error = %v", tt.name, err)
+			continue
+		}
+
+		// the user is set when the config is loaded, so it is shown by config show and not only used once validated
+		if got := f.Channels["mail"].Email.AuthUser; got != tt.want {
+			t.Errorf("%s: auth_user = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
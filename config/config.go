@@ -1,79 +1,90 @@
 package config
 
 import (
-	"errors"
+	"flag"
 	"fmt"
 	"github.com/hashicorp/go-hclog"
 	"os"
+	"strings"
 )
 
-// errors
-var (
-	errEndpoint = errors.New("endpoint flag is mandatory")
-	errNotify   = errors.New("notify flag is mandatory")
-	errResponse = errors.New("response flag is mandatory")
-	errToField  = errors.New("email TO field not defined")
-)
+func (f *Config) withDefaults() {
+	f.NotifyService = notifyDefault
+	f.Interval = intervalDefault
+	f.Timeout = timeoutDefault
+	f.RetryDelay = retryDelayDefault
+	f.Loglevel = logLevelDefault
 
-func cmdGenerateConfigFile() bool {
-	if len(os.Args) < 2 {
-		return false
-	} else {
-		cmdArgs := os.Args[1:2]
-		return cmdArgs[0] == "config"
-	}
-}
+	f.Delivery.Retries = deliveryRetriesDefault
+	f.Delivery.InitialBackoff = deliveryInitialBackoffDefault
+	f.Delivery.MaxBackoff = deliveryMaxBackoffDefault
+	f.Delivery.SpoolDir = deliverySpoolDirDefault
 
-func (f *Config) checkRequiredData() error {
+	f.Policy.RateWindow = policyRateWindowDefault
 
-	if f.MonitoredServices.Http[0].Endpoint == "" {
-		return errEndpoint
-	}
+	f.Digest.BypassSeverity = digestBypassSeverityDefault
 
-	if f.NotifyService == "" {
-		return errNotify
-	}
+	f.Services.Email.SMTPServer = smtpServerDefault
+	f.Services.Email.UseAuth = smtpAuthDefault
+	f.Services.Email.SMTPPort = smtpPortDefault
+	f.Services.Email.From = emailFromDefault
+	f.Services.Email.Subject = emailSubjectDefault
 
-	if f.MonitoredServices.Http[0].ExpectedResponse == "" {
-		return errResponse
-	}
+	f.Services.Sms.BaseUrl = smsBaseUrlDefault
+	f.Services.Sms.MaxLength = smsMaxLengthDefault
 
-	if f.Services.Email.To[0] == "" {
-		return errToField
-	}
+	f.Services.Exec.Timeout = execTimeoutDefault
+
+	f.Services.Syslog.Facility = syslogFacilityDefault
+	f.Services.Syslog.AppName = syslogAppNameDefault
+}
+
+// withMonitorDefaults sets the defaults of the monitor settings which were not defined
+func (f *Config) withMonitorDefaults() {
+	for i := range f.MonitoredServices.Http {
+		mon := &f.MonitoredServices.Http[i]
 
-	if f.Services.Email.UseAuth {
-		if f.Services.Email.AuthUser == "" {
-			f.Services.Email.AuthUser = f.Services.Email.From
+		mon.Name = monitorName(*mon)
+
+		if mon.Severity == "" {
+			mon.Severity = severityDefault
 		}
 
-		if f.Services.Email.AuthPass == "" {
-			//TODO: get smtp pass from env var
-			return errors.New("smtp auth password not provided")
+		// only the settings which are not set fall back to the global ones, so they can be set to zero
+		mon.inherited = nil
+		if mon.Interval == nil {
+			interval := f.Interval
+			mon.Interval = &interval
+			mon.inherited = append(mon.inherited, "interval")
+		}
+		if mon.Timeout == nil {
+			timeout := f.Timeout
+			mon.Timeout = &timeout
+			mon.inherited = append(mon.inherited, "timeout")
+		}
+		if mon.Retries == nil {
+			retries := f.Retries
+			mon.Retries = &retries
+			mon.inherited = append(mon.inherited, "retries")
+		}
+		if mon.RetryDelay == nil {
+			retryDelay := f.RetryDelay
+			mon.RetryDelay = &retryDelay
+			mon.inherited = append(mon.inherited, "retry_delay")
 		}
 	}
-
-	return nil
 }
 
-func (f *Config) withDefaults() {
-	f.NotifyService = notifyDefault
-	f.Interval = intervalDefault
-	f.Timeout = timeoutDefault
-	f.Loglevel = logLevelDefault
-
-	f.Services.Email.SMTPServer = smtpServerDefault
-	f.Services.Email.UseAuth = smtpAuthDefault
-	f.Services.Email.SMTPPort = smtpPortDefault
-	f.Services.Email.From = emailFromDefault
-	f.Services.Email.Subject = emailSubjectDefault
-
-	f.MonitoredServices.Http = []Monitor{
-		{
-			Endpoint:         "",
-			ExpectedResponse: "",
-		},
+// withApiDefaults points the acknowledge links to the local server if the external url is not set
+func (f *Config) withApiDefaults() {
+	if f.Api.Listen != "" && f.Api.ExternalUrl == "" {
+		host := f.Api.Listen
+		if strings.HasPrefix(host, ":") {
+			host = "localhost" + host
+		}
+		f.Api.ExternalUrl = "http://" + host
 	}
+	f.Api.ExternalUrl = strings.TrimSuffix(f.Api.ExternalUrl, "/")
 }
 
 func (f *Config) newLogger(name string, logfileLocation string) (hclog.Logger, error) {
@@ -93,25 +104,48 @@ func (f *Config) newLogger(name string, logfileLocation string) (hclog.Logger, e
 	return hclog.New(logConfig), nil
 }
 
-func NewConfig() (*Config, error) {
+// prepare resolves the notification services and sets the defaults which depend on the loaded configuration
+func (f *Config) prepare() error {
+	if err := f.resolveChannels(); err != nil {
+		return fmt.Errorf("could not load notification services: %w", err)
+	}
+
+	f.withMonitorDefaults()
+	f.withApiDefaults()
+
+	return nil
+}
+
+// NewConfig loads the configuration from the flags in args, registered on the provided flag set, and the config file
+func NewConfig(flags *flag.FlagSet, args []string) (*Config, error) {
+	config, err := Load(flags, args)
+	if err != nil {
+		return nil, err
+	}
+
+	// check if all required data is present
+	if err := config.Check(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return config, nil
+}
+
+// Load loads the configuration like NewConfig, without checking it
+func Load(flags *flag.FlagSet, args []string) (*Config, error) {
 	config := &Config{}
 	// get default values
 	config.withDefaults()
-	// if config arg is set we will generate example config file
-	if cmdGenerateConfigFile() {
-		if err := config.createConfigFileWithDefaults(); err != nil {
-			return nil, fmt.Errorf("could not create default config file %w", err)
-		}
-		// after we've generated the config file exit the program
-		fmt.Println("config file successfully generated")
-		os.Exit(0)
-	}
 
 	// load the configuration parameters
-	if err := config.getConfig(); err != nil {
+	if err := config.getConfig(flags, args); err != nil {
 		return nil, fmt.Errorf("could not load configuration: %w", err)
 	}
 
+	if err := config.prepare(); err != nil {
+		return nil, err
+	}
+
 	// set up logger
 	newLogger, err := config.newLogger("go-notify", config.LogFileName)
 	if err != nil {
@@ -120,11 +154,5 @@ func NewConfig() (*Config, error) {
 
 	config.Logger = newLogger
 
-	// check if all required data is present
-	if err := config.checkRequiredData(); err != nil {
-		config.Logger.Named("command").Error("required parameters not set", "error", err)
-		os.Exit(1)
-	}
-
 	return config, nil
 }
@@ -4,30 +4,158 @@ import (
 	"fmt"
 	"gopkg.in/yaml.v3"
 	"os"
+	"path/filepath"
 )
 
+// loadFromConfigFile loads the config file, or every yaml file of the config directory, and the files they include,
+// merging them into a single config
 func (f *Config) loadFromConfigFile() error {
-	confFileBuff, err := os.ReadFile(f.ConfigFile)
+	main, included, err := configFiles(f.ConfigFile)
 	if err != nil {
-		return fmt.Errorf("could not read config file %w", err)
+		return err
 	}
 
-	if err := yaml.Unmarshal(confFileBuff, f); err != nil {
-		return fmt.Errorf("could not unmarshal yaml file: %w", err)
+	merged := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	f.files = nil
+	offset := 0
+
+	for i, name := range append(main, included...) {
+		root, lines, err := readConfigFile(name)
+		if err != nil {
+			return err
+		}
+
+		file := configFile{name: name, offset: offset, lines: filepath.Ext(name) != ".toml"}
+		// the settings of a file without lines are all put on its single line
+		if !file.lines {
+			shiftLines(root, 1)
+		}
+		shiftLines(root, offset)
+
+		f.files = append(f.files, file)
+		offset += lines
+
+		// an empty file has no content
+		if len(root.Content) == 0 {
+			continue
+		}
+
+		mapping := root.Content[0]
+		if mapping.Kind != yaml.MappingNode {
+			return fmt.Errorf("config file %s must be a mapping of the settings", name)
+		}
+
+		if i >= len(main) {
+			if include, line := mappingValue(mapping, "include"); include != nil {
+				f.problems = append(f.problems, Problem{
+					Path:    "include",
+					Line:    line,
+					Message: "include is only read from the main config files",
+				})
+			}
+		}
+
+		f.merge(merged, mapping, "")
+	}
+
+	f.node = &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{merged}}
+
+	// the fields with the wrong type are reported as problems of the config, the rest is still decoded
+	if err := f.node.Decode(f); err != nil {
+		typeErr, ok := err.(*yaml.TypeError)
+		if !ok {
+			return fmt.Errorf("could not unmarshal yaml file: %w", err)
+		}
+		f.addTypeError(typeErr)
 	}
 
 	return nil
 }
 
-func (f *Config) createConfigFileWithDefaults() error {
-	buff, err := yaml.Marshal(f)
+// exampleComments annotate the settings of the example config file, keyed by their path without the list indexes
+var exampleComments = map[string]string{
+	"monitored_services.http":               "the monitored http endpoints, an endpoint is healthy if its response contains the expected response",
+	"monitored_services.http.severity":      "info, warning or critical",
+	"notify_service":                        "notification service used for the monitors without an escalation policy or route",
+	"interval":                              "time between two health checks, like 30s, 5m or 1h30m, a number is taken as seconds",
+	"timeout":                               "time after which an endpoint is considered unresponsive",
+	"retries":                               "number of times a failed check is repeated before the endpoint is considered down",
+	"retry_delay":                           "time between the repeated checks",
+	"log_level":                             "INFO or DEBUG",
+	"log_filename":                          "logs are written to the console if empty",
+	"notification_services":                 "named notification service instances, the type is taken from the name if it is not set",
+	"notification_services.email.auth_pass": "can be read from a file with auth_pass_file",
+	"notification_services.email.use_auth":  "set to true if the smtp server requires authentication",
+	"notification_services.syslog.network":  "udp, tcp, tls, unix or unixgram, leave empty to use the local syslog socket",
+	"delivery":                              "failed notifications are retried with exponential backoff, and spooled if all retries fail",
+	"policy.renotify_interval":              "minimum time between two notifications for the same endpoint",
+	"policy.rate_limit":                     "maximum notifications per channel in the rate window, 0 disables the limit",
+	"digest":                                "default digest of the notification services, each of them can replace it with its own digest settings",
+	"digest.window":                         "time over which the notifications are combined in a digest, 0 disables the digest",
+	"digest.bypass_severity":                "alerts of this or higher severity are sent right away, empty by default to digest everything",
+	"api":                                   "server used to acknowledge incidents and manage silences",
+	"api.listen":                            "the server is disabled if empty",
+	"api.external_url":                      "address used in the acknowledge links, defaults to the listen address",
+	"api.secret":                            "key used to sign the acknowledge links",
+	"api.token":                             "bearer token required by the JSON API",
+}
+
+// WriteExample writes a config file with the default settings of every notification service,
+// annotated with comments. An existing file is only replaced if force is set.
+func WriteExample(path string, force bool) error {
+	f := &Config{}
+	f.withDefaults()
+	f.withDefaultChannels()
+
+	f.MonitoredServices.Http = []Monitor{
+		{
+			Endpoint:         "",
+			ExpectedResponse: "",
+			ExpectedStatus:   200,
+			Severity:         severityDefault,
+		},
+	}
+
+	var root yaml.Node
+	if err := root.Encode(f); err != nil {
+		return fmt.Errorf("could not marshal Config struct: %w", err)
+	}
+	annotate(&root, "")
+
+	buff, err := yaml.Marshal(&root)
 	if err != nil {
 		return fmt.Errorf("could not marshal Config struct: %w", err)
 	}
 
-	if err := os.WriteFile("config.yaml", buff, 655); err != nil {
+	if !force {
+		if _, err := os.Stat(path); err == nil {
+			return fmt.Errorf("config file %s already exists", path)
+		}
+	}
+
+	if err := os.WriteFile(path, buff, 0644); err != nil {
 		return fmt.Errorf("could not write config file: %w", err)
 	}
 
 	return nil
 }
+
+// annotate adds the example comments to the settings of the node
+func annotate(node *yaml.Node, path string) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			if path != "" {
+				key = path + "." + key
+			}
+
+			node.Content[i].HeadComment = exampleComments[key]
+			annotate(node.Content[i+1], key)
+		}
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			annotate(item, path)
+		}
+	}
+}
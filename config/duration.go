@@ -0,0 +1,82 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Duration is a time setting, written as a Go duration like 30s, 5m or 1h30m, or as a number of seconds
+type Duration time.Duration
+
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+// Set parses the duration of a flag or an environment variable
+func (d *Duration) Set(value string) error {
+	parsed, err := parseDuration(value)
+	if err != nil {
+		return err
+	}
+
+	*d = parsed
+	return nil
+}
+
+// UnmarshalYAML parses the duration of the config file, the invalid ones are reported as type errors,
+// so they are listed with the other problems of the config
+func (d *Duration) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.ScalarNode {
+		return &yaml.TypeError{Errors: []string{fmt.Sprintf("line %d: cannot unmarshal %s into a duration", node.Line, node.ShortTag())}}
+	}
+
+	parsed, err := parseDuration(node.Value)
+	if err != nil {
+		return &yaml.TypeError{Errors: []string{fmt.Sprintf("line %d: %s", node.Line, err)}}
+	}
+
+	*d = parsed
+	return nil
+}
+
+func (d Duration) MarshalYAML() (interface{}, error) {
+	return d.String(), nil
+}
+
+// UnmarshalJSON parses the duration of a JSON request, given as a string or as a number of seconds
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	value := string(data)
+	if strings.HasPrefix(value, `"`) {
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+	}
+
+	return d.Set(value)
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// parseDuration parses a Go duration, or a whole number of seconds
+func parseDuration(value string) (Duration, error) {
+	value = strings.TrimSpace(value)
+
+	// the seconds which do not fit in a duration are reported as invalid
+	if seconds, err := strconv.ParseUint(value, 10, 64); err == nil && seconds <= math.MaxInt64/uint64(time.Second) {
+		return Duration(time.Duration(seconds) * time.Second), nil
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil || parsed < 0 {
+		return 0, fmt.Errorf("invalid duration %q, expected a duration like 30s, 5m or 1h30m, or a number of seconds", value)
+	}
+
+	return Duration(parsed), nil
+}
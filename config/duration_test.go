@@ -0,0 +1,82 @@
+package config
+
+import (
+	"encoding/json"
+	"gopkg.in/yaml.v3"
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{value: "0", want: 0},
+		{value: "30", want: 30 * time.Second},
+		{value: " 45 ", want: 45 * time.Second},
+		{value: "30s", want: 30 * time.Second},
+		{value: "5m", want: 5 * time.Minute},
+		{value: "1h30m", want: 90 * time.Minute},
+		{value: "1.5h", want: 90 * time.Minute},
+		{value: "500ms", want: 500 * time.Millisecond},
+		{value: "0s", want: 0},
+		{value: "", wantErr: true},
+		{value: "-1", wantErr: true},
+		{value: "-5m", wantErr: true},
+		{value: "1.5", wantErr: true},
+		{value: "5 m", wantErr: true},
+		{value: "five", wantErr: true},
+		{value: "9223372036", want: 9223372036 * time.Second},
+		{value: "9223372037", wantErr: true},
+		{value: "99999999999999999999", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseDuration(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseDuration(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+
+		if err == nil && time.Duration(got) != tt.want {
+			t.Errorf("parseDuration(%q) = %v, want %v", tt.value, time.Duration(got), tt.want)
+		}
+	}
+}
+
+func TestDurationUnmarshal(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		json    string
+		want    time.Duration
+		wantErr bool
+	}{
+		{name: "seconds", yaml: "90", json: "90", want: 90 * time.Second},
+		{name: "duration", yaml: "2h", json: `"2h"`, want: 2 * time.Hour},
+		{name: "quoted seconds", yaml: `"90"`, json: `"90"`, want: 90 * time.Second},
+		{name: "invalid", yaml: "soon", json: `"soon"`, wantErr: true},
+		{name: "negative", yaml: "-1m", json: "-60", wantErr: true},
+		{name: "not a scalar", yaml: "[1m]", json: `["1m"]`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		var fromYaml Duration
+		err := yaml.Unmarshal([]byte(tt.yaml), &fromYaml)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: yaml error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		} else if err == nil && time.Duration(fromYaml) != tt.want {
+			t.Errorf("%s: yaml = %v, want %v", tt.name, time.Duration(fromYaml), tt.want)
+		}
+
+		var fromJson Duration
+		err = json.Unmarshal([]byte(tt.json), &fromJson)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: json error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		} else if err == nil && time.Duration(fromJson) != tt.want {
+			t.Errorf("%s: json = %v, want %v", tt.name, time.Duration(fromJson), tt.want)
+		}
+	}
+}
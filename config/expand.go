@@ -0,0 +1,137 @@
+package config
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// envPattern matches ${VAR} and ${VAR:-default}, $${VAR} is left as a literal ${VAR}
+var envPattern = regexp.MustCompile(`\$?\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// secretKeys are the settings that can also be read from a file with the _file suffix, e.g. auth_pass_file
+var secretKeys = map[string]bool{
+	"auth_pass":    true,
+	"access_token": true,
+	"token":        true,
+	"password":     true,
+	"app_token":    true,
+	"auth_token":   true,
+	"webhook":      true,
+	"secret":       true,
+}
+
+// expandNode replaces the environment variables in all values of the config file,
+// and the secret _file settings with the content of the files, relative to the config file directory
+func expandNode(node *yaml.Node, dir string) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		value, err := expandEnv(node.Value)
+		if err != nil {
+			return lineError(node, err)
+		}
+
+		// an expanded plain value is resolved again, so it can be a number or a bool and not only a string
+		if value != node.Value && node.Style == 0 {
+			node.Tag = ""
+		}
+		node.Value = value
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if err := expandNode(node.Content[i+1], dir); err != nil {
+				return err
+			}
+
+			if err := readSecretFile(node, i, dir); err != nil {
+				return err
+			}
+		}
+	default:
+		for _, child := range node.Content {
+			if err := expandNode(child, dir); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// expandEnv replaces ${VAR} with the value of the environment variable, failing if it is not set and has no default
+func expandEnv(value string) (string, error) {
+	var missing []string
+
+	expanded := envPattern.ReplaceAllStringFunc(value, func(match string) string {
+		if strings.HasPrefix(match, "$$") {
+			return match[1:]
+		}
+
+		groups := envPattern.FindStringSubmatch(match)
+		if env, ok := os.LookupEnv(groups[1]); ok {
+			return env
+		}
+
+		if groups[2] != "" {
+			return groups[3]
+		}
+
+		missing = append(missing, groups[1])
+		return ""
+	})
+
+	if len(missing) > 0 {
+		return "", fmt.Errorf("environment variable %s is not set", strings.Join(missing, ", "))
+	}
+
+	return expanded, nil
+}
+
+// readSecretFile turns the secret _file setting at index i of the mapping into the setting itself,
+// holding the content of the file without the trailing new line
+func readSecretFile(mapping *yaml.Node, i int, dir string) error {
+	key, value := mapping.Content[i], mapping.Content[i+1]
+
+	name := strings.TrimSuffix(key.Value, "_file")
+	if name == key.Value || !secretKeys[name] {
+		return nil
+	}
+
+	for j := 0; j+1 < len(mapping.Content); j += 2 {
+		if mapping.Content[j].Value == name && mapping.Content[j+1].Value != "" {
+			return lineError(key, fmt.Errorf("only one of %s and %s can be set", name, key.Value))
+		}
+	}
+
+	path := value.Value
+	if path == "" {
+		return nil
+	}
+
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return lineError(key, fmt.Errorf("could not read %s: %w", key.Value, err))
+	}
+
+	key.Value = name
+	value.Value = strings.TrimRight(string(content), "\r\n")
+	value.Tag = "!!str"
+	value.Style = 0
+
+	return nil
+}
+
+// lineError prefixes the error with the line of the node, if the line is known
+func lineError(node *yaml.Node, err error) error {
+	if node.Line == 0 {
+		return err
+	}
+
+	return fmt.Errorf("line %d: %w", node.Line, err)
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"gopkg.in/yaml.v3"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestExpandNode(t *testing.T) {
+	type settings struct {
+		Port     uint64   `yaml:"port"`
+		UseAuth  bool     `yaml:"use_auth"`
+		Retries  int      `yaml:"retries"`
+		Interval Duration `yaml:"interval"`
+		User     string   `yaml:"user"`
+		Token    string   `yaml:"token"`
+	}
+
+	t.Setenv("TEST_PORT", "587")
+	t.Setenv("TEST_AUTH", "true")
+	t.Setenv("TEST_NUMBER", "42")
+
+	tests := []struct {
+		name    string
+		yaml    string
+		want    settings
+		wantErr bool
+	}{
+		{name: "int", yaml: "port: ${TEST_PORT}", want: settings{Port: 587}},
+		{name: "bool", yaml: "use_auth: ${TEST_AUTH}", want: settings{UseAuth: true}},
+		{name: "signed int", yaml: "retries: ${TEST_NUMBER}", want: settings{Retries: 42}},
+		{name: "duration", yaml: "interval: ${TEST_NUMBER}", want: settings{Interval: Duration(42 * time.Second)}},
+		{name: "default", yaml: "port: ${TEST_MISSING:-25}", want: settings{Port: 25}},
+		{name: "part of a string", yaml: "user: admin-${TEST_NUMBER}", want: settings{User: "admin-42"}},
+		{name: "number as string", yaml: "token: ${TEST_NUMBER}", want: settings{Token: "42"}},
+		{name: "quoted number as string", yaml: `token: "${TEST_NUMBER}"`, want: settings{Token: "42"}},
+		{name: "escaped", yaml: "user: $${TEST_NUMBER}", want: settings{User: "${TEST_NUMBER}"}},
+		{name: "quoted bool", yaml: `use_auth: "${TEST_AUTH}"`, wantErr: true},
+		{name: "missing", yaml: "port: ${TEST_MISSING}", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		var root yaml.Node
+		if err := yaml.Unmarshal([]byte(tt.yaml), &root); err != nil {
+			t.Fatalf("%s: could not parse: %v", tt.name, err)
+		}
+
+		var got settings
+		err := expandNode(&root, ".")
+		if err == nil {
+			err = root.Decode(&got)
+		}
+
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+
+		if err == nil && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: decoded %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
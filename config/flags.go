@@ -1,15 +1,22 @@
 package config
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // default config values
 const (
-	notifyDefault   string = "email"
-	intervalDefault uint64 = 300
-	timeoutDefault  uint64 = 60
+	notifyDefault   string   = "email"
+	intervalDefault Duration = Duration(5 * time.Minute)
+	timeoutDefault  Duration = Duration(time.Minute)
+
+	retryDelayDefault Duration = Duration(5 * time.Second)
 
 	smtpAuthDefault     bool   = false
 	smtpServerDefault   string = "localhost"
@@ -17,12 +24,31 @@ const (
 	emailFromDefault    string = "gonotify@service.check"
 	emailSubjectDefault string = "[GONOTIFY] SERVICE ENTERED AN ALARM STATE"
 	logLevelDefault     string = "INFO"
+	severityDefault     string = "critical"
+
+	deliveryRetriesDefault        uint64   = 5
+	deliveryInitialBackoffDefault Duration = Duration(2 * time.Second)
+	deliveryMaxBackoffDefault     Duration = Duration(time.Minute)
+	deliverySpoolDirDefault       string   = "spool"
+
+	policyRateWindowDefault Duration = Duration(time.Hour)
+
+	// nothing bypasses the digest by default, as the monitors are critical unless they set their severity
+	digestBypassSeverityDefault string = ""
+
+	smsBaseUrlDefault   string = "https://api.twilio.com"
+	smsMaxLengthDefault int    = 160
+
+	execTimeoutDefault Duration = Duration(30 * time.Second)
+
+	syslogFacilityDefault string = "daemon"
+	syslogAppNameDefault  string = "go-notify"
 )
 
 type arrayFlags []string
 
 func (a arrayFlags) String() string {
-	return "array flag"
+	return strings.Join(a, ",")
 }
 
 func (a *arrayFlags) Set(s string) error {
@@ -30,28 +56,153 @@ func (a *arrayFlags) Set(s string) error {
 	return nil
 }
 
-func (f *Config) getConfig() error {
-	flag.StringVar(&f.ConfigFile, "config", "", "Config file location ( .yaml, .yml )")
-	flag.StringVar(&f.NotifyService, "notify", notifyDefault, "Service used for notification to notify (email, slack)")
-	//flag.StringVar(&f.MonitoredServices.Http[0].Endpoint, "endpoint", "", "Endpoint to monitor")
-	//flag.StringVar(&f.Response, "resp-str", "", "Expected string in response")
-	flag.Uint64Var(&f.Interval, "interval", intervalDefault, "Interval in seconds to query the endpoint")
-	flag.Uint64Var(&f.Timeout, "timeout", timeoutDefault, "Timeout in seconds to consider an endpoint unresponsive")
-	flag.StringVar(&f.Loglevel, "log-level", logLevelDefault, "Log level output (INFO, DEBUG)")
-	flag.StringVar(&f.LogFileName, "log-file", "", "Log file name to output all logs")
-
-	flag.BoolVar(&f.Services.Email.UseAuth, "smtp-auth", smtpAuthDefault, "Set to true if your SMTP server requires SMTP authentication")
-	flag.StringVar(&f.Services.Email.SMTPServer, "smtp-server", smtpServerDefault, "SMTP server and port that will be used to send email")
-	flag.Uint64Var(&f.Services.Email.SMTPPort, "smtp-port", smtpPortDefault, "SMTP server port")
-	flag.Var(&f.Services.Email.To, "email-to", "Email addresses to send the notification")
-	flag.Var(&f.Services.Email.Cc, "email-cc", "Email addresses for cc field")
-	flag.Var(&f.Services.Email.Bcc, "email-bcc", "Email addresses bcc field")
-	flag.StringVar(&f.Services.Email.From, "email-from", emailFromDefault, "Email address from which to send the notification")
-	flag.StringVar(&f.Services.Email.Subject, "email-subject", emailSubjectDefault, "Subject of the notification email")
-	flag.StringVar(&f.Services.Email.Body, "email-body", "", "Body of the notification email")
-	flag.StringVar(&f.Services.Email.AuthUser, "smtp-user", "", "SMTP user used for authentication")
-	flag.StringVar(&f.Services.Email.AuthPass, "smtp-pass", "", "SMTP pass used for authentication")
-	flag.Parse()
+// monitorFlags are the monitors set with a repeatable flag, each as comma separated key=value pairs
+type monitorFlags []Monitor
+
+func (m monitorFlags) String() string {
+	return "monitor flag"
+}
+
+func (m *monitorFlags) Set(s string) error {
+	mon := Monitor{flag: len(*m) + 1}
+
+	for _, pair := range strings.Split(s, ",") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			return fmt.Errorf("%q must be in key=value format", pair)
+		}
+
+		switch key, value := strings.TrimSpace(kv[0]), kv[1]; key {
+		case "url":
+			mon.Endpoint = value
+		case "expect":
+			mon.ExpectedResponse = value
+		case "status":
+			status, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("status %q is not a number", value)
+			}
+			mon.ExpectedStatus = status
+		case "name":
+			mon.Name = value
+		case "severity":
+			mon.Severity = value
+		case "retries":
+			retries, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				return fmt.Errorf("retries %q is not a number", value)
+			}
+			mon.Retries = &retries
+		case "interval", "timeout", "retry_delay":
+			duration, err := parseDuration(value)
+			if err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+
+			switch key {
+			case "interval":
+				mon.Interval = &duration
+			case "timeout":
+				mon.Timeout = &duration
+			default:
+				mon.RetryDelay = &duration
+			}
+		default:
+			return fmt.Errorf("unknown key %q, expected url, expect, status, name, severity, interval, timeout, retries or retry_delay", key)
+		}
+	}
+
+	if mon.Endpoint == "" {
+		return errors.New("url is not set")
+	}
+
+	*m = append(*m, mon)
+	return nil
+}
+
+// flagPaths are the config file paths of the settings set by the flags. The paths of the notification service
+// settings start with their type, as the flags apply to every instance of the type.
+var flagPaths = map[string]string{
+	"config":            "config_file",
+	"notify":            "notify_service",
+	"interval":          "interval",
+	"timeout":           "timeout",
+	"log-level":         "log_level",
+	"log-file":          "log_filename",
+	"delivery-retries":  "delivery.retries",
+	"spool-dir":         "delivery.spool_dir",
+	"renotify-interval": "policy.renotify_interval",
+	"rate-limit":        "policy.rate_limit",
+	"rate-window":       "policy.rate_window",
+	"digest-window":     "digest.window",
+	"api-listen":        "api.listen",
+
+	"smtp-auth":     "email.use_auth",
+	"smtp-server":   "email.smtp_server",
+	"smtp-port":     "email.smtp_port",
+	"email-to":      "email.to",
+	"email-cc":      "email.cc",
+	"email-bcc":     "email.bcc",
+	"email-from":    "email.from",
+	"email-subject": "email.subject",
+	"email-body":    "email.body",
+	"smtp-user":     "email.auth_user",
+	"smtp-pass":     "email.auth_pass",
+	"sms-to":        "sms.to",
+}
+
+// override is a setting of a flag applied over the config file, set by its environment variable or the flag itself
+type override struct {
+	flag  string
+	value string
+	// source is the environment variable or the flag the value came from
+	source string
+}
+
+// envName returns the environment variable of the flag, e.g. GONOTIFY_SMTP_SERVER for smtp-server
+func envName(flag string) string {
+	return "GONOTIFY_" + strings.ToUpper(strings.ReplaceAll(flag, "-", "_"))
+}
+
+// getConfig parses the flags from args into the config and loads the config file if it is set. The settings
+// are layered, the defaults are replaced by the config file, which is replaced by the GONOTIFY_ environment
+// variables, which are replaced by the explicitly set flags.
+func (f *Config) getConfig(flags *flag.FlagSet, args []string) error {
+	flags.StringVar(&f.ConfigFile, "config", "", "Config file location ( .yaml, .yml, .json, .toml ), or a directory whose config files are merged")
+	flags.Var(&f.flagMonitors, "http", "Http endpoint to monitor as url=<url>,expect=<response>,status=<code>,name=<name>,severity=<severity>, "+
+		"optionally with interval=<duration>,timeout=<duration>,retries=<count>,retry_delay=<duration>, "+
+		"url and expect or status are required, can be repeated. Replaces the monitor with the same name in the config file")
+	flags.StringVar(&f.NotifyService, "notify", notifyDefault, "Notification service used to notify, the name of a notification_services entry or a type (email, slack, matrix, ntfy, gotify, sms, exec, syslog)")
+	flags.Var(&f.Interval, "interval", "Interval to query the endpoint, as a duration like 30s or 5m, or a number of seconds")
+	flags.Var(&f.Timeout, "timeout", "Timeout to consider an endpoint unresponsive, as a duration like 30s or 5m, or a number of seconds")
+	flags.StringVar(&f.Loglevel, "log-level", logLevelDefault, "Log level output (INFO, DEBUG)")
+	flags.StringVar(&f.LogFileName, "log-file", "", "Log file name to output all logs")
+	flags.Uint64Var(&f.Delivery.Retries, "delivery-retries", deliveryRetriesDefault, "Number of times a failed notification is retried before it is spooled")
+	flags.StringVar(&f.Delivery.SpoolDir, "spool-dir", deliverySpoolDirDefault, "Directory where undelivered notifications are stored")
+	flags.Var(&f.Policy.RenotifyInterval, "renotify-interval", "Minimum interval between two notifications for the same endpoint, as a duration like 30m or a number of seconds")
+	flags.Uint64Var(&f.Policy.RateLimit, "rate-limit", 0, "Maximum number of notifications sent per channel in the rate window, 0 disables the limit")
+	flags.Var(&f.Policy.RateWindow, "rate-window", "Rate limit window, as a duration like 1h or a number of seconds")
+	flags.Var(&f.Digest.Window, "digest-window", "Window over which notifications are combined in a digest, as a duration like 5m or a number of seconds, 0 disables the digest")
+	flags.StringVar(&f.Api.Listen, "api-listen", "", "Address of the HTTP server used to acknowledge incidents, empty disables the server")
+
+	f.Services.registerFlags(flags)
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	f.args = args
+
+	explicit := map[string]string{}
+	flags.Visit(func(fl *flag.Flag) {
+		if _, ok := flagPaths[fl.Name]; ok {
+			explicit[fl.Name] = fl.Value.String()
+		}
+	})
+
+	// the config file has to be known before the other environment variables are applied over it
+	if _, ok := explicit["config"]; !ok {
+		f.ConfigFile = os.Getenv(envName("config"))
+	}
 
 	if f.ConfigFile != "" {
 		if err := f.loadFromConfigFile(); err != nil {
@@ -59,5 +210,107 @@ func (f *Config) getConfig() error {
 		}
 	}
 
+	// the flags are visited in lexical order, so are the environment variables
+	f.overrides = nil
+	flags.VisitAll(func(fl *flag.Flag) {
+		if _, ok := flagPaths[fl.Name]; !ok {
+			return
+		}
+
+		if _, ok := explicit[fl.Name]; ok {
+			return
+		}
+
+		if value, ok := os.LookupEnv(envName(fl.Name)); ok {
+			f.overrides = append(f.overrides, override{flag: fl.Name, value: value, source: "env " + envName(fl.Name)})
+		}
+	})
+	flags.Visit(func(fl *flag.Flag) {
+		if value, ok := explicit[fl.Name]; ok {
+			f.overrides = append(f.overrides, override{flag: fl.Name, value: value, source: "flag -" + fl.Name})
+		}
+	})
+
+	if err := f.applyOverrides(flags); err != nil {
+		return err
+	}
+
+	f.mergeFlagMonitors()
+
 	return nil
 }
+
+// registerFlags registers the flags of the notification service settings. The current settings are the defaults,
+// so the flags can also be registered on the settings of a channel instance.
+func (n *NotificationServices) registerFlags(flags *flag.FlagSet) {
+	flags.BoolVar(&n.Email.UseAuth, "smtp-auth", n.Email.UseAuth, "Set to true if your SMTP server requires SMTP authentication")
+	flags.StringVar(&n.Email.SMTPServer, "smtp-server", n.Email.SMTPServer, "SMTP server and port that will be used to send email")
+	flags.Uint64Var(&n.Email.SMTPPort, "smtp-port", n.Email.SMTPPort, "SMTP server port")
+	flags.Var(&n.Email.To, "email-to", "Email addresses to send the notification")
+	flags.Var(&n.Email.Cc, "email-cc", "Email addresses for cc field")
+	flags.Var(&n.Email.Bcc, "email-bcc", "Email addresses bcc field")
+	flags.StringVar(&n.Email.From, "email-from", n.Email.From, "Email address from which to send the notification")
+	flags.StringVar(&n.Email.Subject, "email-subject", n.Email.Subject, "Subject of the notification email")
+	flags.StringVar(&n.Email.Body, "email-body", n.Email.Body, "Body of the notification email")
+	flags.StringVar(&n.Email.AuthUser, "smtp-user", n.Email.AuthUser, "SMTP user used for authentication")
+	flags.StringVar(&n.Email.AuthPass, "smtp-pass", n.Email.AuthPass, "SMTP pass used for authentication")
+
+	flags.Var(&n.Sms.To, "sms-to", "Phone numbers to send the SMS notification")
+}
+
+// applyOverrides sets the overrides on the flags registered on the flag set, the others are skipped
+func (f *Config) applyOverrides(flags *flag.FlagSet) error {
+	for _, o := range f.overrides {
+		fl := flags.Lookup(o.flag)
+		if fl == nil {
+			continue
+		}
+
+		// the lists are replaced, with the comma separated values
+		if list, ok := fl.Value.(*arrayFlags); ok {
+			*list = nil
+			if o.value == "" {
+				continue
+			}
+
+			for _, item := range strings.Split(o.value, ",") {
+				*list = append(*list, strings.TrimSpace(item))
+			}
+			continue
+		}
+
+		if err := fl.Value.Set(o.value); err != nil {
+			return fmt.Errorf("invalid value %q of %s: %w", o.value, o.source, err)
+		}
+	}
+
+	return nil
+}
+
+// mergeFlagMonitors adds the monitors set with the flags to the ones of the config file,
+// a monitor of the config file is replaced by the flag monitor with the same name
+func (f *Config) mergeFlagMonitors() {
+	for _, mon := range f.flagMonitors {
+		replaced := false
+
+		for i, existing := range f.MonitoredServices.Http {
+			if monitorName(existing) == monitorName(mon) {
+				f.MonitoredServices.Http[i] = mon
+				replaced = true
+			}
+		}
+
+		if !replaced {
+			f.MonitoredServices.Http = append(f.MonitoredServices.Http, mon)
+		}
+	}
+}
+
+// monitorName returns the name of the monitor, which is its endpoint if the name is not set
+func monitorName(mon Monitor) string {
+	if mon.Name == "" {
+		return mon.Endpoint
+	}
+
+	return mon.Name
+}
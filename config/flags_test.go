@@ -0,0 +1,55 @@
+package config
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRetriesOverrides(t *testing.T) {
+	tests := []struct {
+		name         string
+		env          string
+		args         []string
+		wantRetries  uint64
+		wantDelivery uint64
+	}{
+		{name: "config file", wantRetries: 3, wantDelivery: 4},
+		{name: "environment variable", env: "7", wantRetries: 3, wantDelivery: 7},
+		{name: "flag", args: []string{"-delivery-retries", "8"}, wantRetries: 3, wantDelivery: 8},
+		{name: "flag over environment variable", env: "7", args: []string{"-delivery-retries", "8"}, wantRetries: 3, wantDelivery: 8},
+	}
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("retries: 3\ndelivery:\n  retries: 4\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		if tt.env != "" {
+			os.Setenv("GONOTIFY_DELIVERY_RETRIES", tt.env)
+		} else {
+			os.Unsetenv("GONOTIFY_DELIVERY_RETRIES")
+		}
+
+		flags := flag.NewFlagSet("test", flag.ContinueOnError)
+		flags.SetOutput(io.Discard)
+
+		f, err := Load(flags, append([]string{"-config", path}, tt.args...))
+		if err != nil {
+			t.Errorf("%s: Load error = %v", tt.name, err)
+			continue
+		}
+
+		// the check retries of the config file are not replaced by the delivery retries
+		if f.Retries != tt.wantRetries {
+			t.Errorf("%s: retries = %d, want %d", tt.name, f.Retries, tt.wantRetries)
+		}
+		if f.Delivery.Retries != tt.wantDelivery {
+			t.Errorf("%s: delivery retries = %d, want %d", tt.name, f.Delivery.Retries, tt.wantDelivery)
+		}
+	}
+	os.Unsetenv("GONOTIFY_DELIVERY_RETRIES")
+}
@@ -0,0 +1,244 @@
+package config
+
+import (
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// mergedMappings are the mappings whose settings can come from several config files,
+// the lists of the config files are always merged
+var mergedMappings = map[string]bool{
+	"":                      true,
+	"monitored_services":    true,
+	"notification_services": true,
+	"escalation_policies":   true,
+}
+
+// configExtensions are the extensions of the config files read from a config directory
+var configExtensions = map[string]bool{
+	".yaml": true,
+	".yml":  true,
+	".json": true,
+	".toml": true,
+}
+
+// configFile is a file the config was loaded from. The lines of the files are numbered one after the other,
+// so the lines of a file start after the offset.
+type configFile struct {
+	name   string
+	offset int
+	// lines is false if the lines of the settings are not known, as for the toml files
+	lines bool
+}
+
+// configFiles returns the main config files, the config file itself or the config files of the config directory,
+// and the files matching their include patterns, without duplicates
+func configFiles(location string) (main []string, included []string, err error) {
+	info, err := os.Stat(location)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not read config file %w", err)
+	}
+
+	main = []string{location}
+	if info.IsDir() {
+		if main, err = dirFiles(location); err != nil {
+			return nil, nil, err
+		}
+		if len(main) == 0 {
+			return nil, nil, fmt.Errorf("no .yaml, .yml, .json or .toml files found in %s", location)
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, name := range main {
+		seen[filepath.Clean(name)] = true
+	}
+
+	for _, name := range main {
+		patterns, err := includes(name)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, pattern := range patterns {
+			matches, err := filepath.Glob(pattern)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid include pattern %s in %s: %w", pattern, name, err)
+			}
+			sort.Strings(matches)
+
+			for _, match := range matches {
+				if seen[filepath.Clean(match)] {
+					continue
+				}
+				seen[filepath.Clean(match)] = true
+				included = append(included, match)
+			}
+		}
+	}
+
+	return main, included, nil
+}
+
+// dirFiles returns the config files of the directory in lexical order
+func dirFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("could not read config directory: %w", err)
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && configExtensions[filepath.Ext(entry.Name())] {
+			files = append(files, filepath.Join(dir, entry.Name()))
+		}
+	}
+
+	return files, nil
+}
+
+// includes returns the include patterns of the config file, relative to its directory
+func includes(name string) ([]string, error) {
+	root, _, err := parseConfigFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var header struct {
+		Include []string `yaml:"include"`
+	}
+	if err := root.Decode(&header); err != nil {
+		return nil, fmt.Errorf("could not read the include patterns of %s: %w", name, err)
+	}
+
+	patterns := make([]string, 0, len(header.Include))
+	for _, pattern := range header.Include {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(filepath.Dir(name), pattern)
+		}
+		patterns = append(patterns, pattern)
+	}
+
+	return patterns, nil
+}
+
+// parseConfigFile parses the config file by its extension, returning its root node and the number of its lines.
+// The json files are parsed as yaml, which keeps the lines of their settings. The toml files are converted to
+// a yaml node, without the lines, and are counted as a single line. Any other file is parsed as yaml.
+func parseConfigFile(name string) (*yaml.Node, int, error) {
+	buff, err := os.ReadFile(name)
+	if err != nil {
+		return nil, 0, fmt.Errorf("could not read config file %w", err)
+	}
+
+	var root yaml.Node
+
+	if filepath.Ext(name) == ".toml" {
+		var settings map[string]interface{}
+		if err := toml.Unmarshal(buff, &settings); err != nil {
+			return nil, 0, fmt.Errorf("could not unmarshal toml file %s: %w", name, err)
+		}
+
+		if err := root.Encode(settings); err != nil {
+			return nil, 0, fmt.Errorf("could not convert toml file %s: %w", name, err)
+		}
+
+		return &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{&root}}, 1, nil
+	}
+
+	if err := yaml.Unmarshal(buff, &root); err != nil {
+		return nil, 0, fmt.Errorf("could not unmarshal %s: %w", name, err)
+	}
+
+	return &root, strings.Count(string(buff), "\n") + 1, nil
+}
+
+// readConfigFile parses the config file and expands its values, returning its root node and the number of its lines
+func readConfigFile(name string) (*yaml.Node, int, error) {
+	root, lines, err := parseConfigFile(name)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if err := expandNode(root, filepath.Dir(name)); err != nil {
+		return nil, 0, fmt.Errorf("could not expand config file %s: %w", name, err)
+	}
+
+	return root, lines, nil
+}
+
+// shiftLines moves the lines of the node and its children by the offset
+func shiftLines(node *yaml.Node, offset int) {
+	node.Line += offset
+	for _, child := range node.Content {
+		shiftLines(child, offset)
+	}
+}
+
+// merge adds the settings of the mapping node to the merged mapping at the path. The lists are appended,
+// and the mappings which can come from several files are merged. Any other setting that is already set
+// is reported as a problem, naming the files of both settings.
+func (f *Config) merge(merged, node *yaml.Node, path string) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+
+		keyPath := key.Value
+		if path != "" {
+			keyPath = path + "." + key.Value
+		}
+
+		existing, existingKey := -1, (*yaml.Node)(nil)
+		for j := 0; j+1 < len(merged.Content); j += 2 {
+			if merged.Content[j].Value == key.Value {
+				existing, existingKey = j+1, merged.Content[j]
+			}
+		}
+
+		switch {
+		case existing < 0:
+			merged.Content = append(merged.Content, key, value)
+		case value.Kind == yaml.SequenceNode && merged.Content[existing].Kind == yaml.SequenceNode:
+			merged.Content[existing].Content = append(merged.Content[existing].Content, value.Content...)
+		case mergedMappings[keyPath] && value.Kind == yaml.MappingNode && merged.Content[existing].Kind == yaml.MappingNode:
+			f.merge(merged.Content[existing], value, keyPath)
+		default:
+			f.problems = append(f.problems, Problem{
+				Path:    keyPath,
+				Line:    key.Line,
+				Message: fmt.Sprintf("%s is already set in %s", keyPath, f.location(existingKey.Line)),
+			})
+		}
+	}
+}
+
+// position returns the config file and its line of the line of the merged config files,
+// the line is 0 if the lines of the file are not known
+func (f *Config) position(line int) (string, int) {
+	for i := len(f.files) - 1; i >= 0; i-- {
+		if line <= f.files[i].offset {
+			continue
+		}
+
+		if !f.files[i].lines {
+			return f.files[i].name, 0
+		}
+		return f.files[i].name, line - f.files[i].offset
+	}
+
+	return f.ConfigFile, line
+}
+
+// location returns the config file and the line of the line of the merged config files as file:line
+func (f *Config) location(line int) string {
+	file, line := f.position(line)
+	if line == 0 {
+		return file
+	}
+
+	return fmt.Sprintf("%s:%d", file, line)
+}
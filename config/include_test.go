@@ -0,0 +1,203 @@
+package config
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name     string
+		main     string
+		included string
+		want     string
+		problems []string
+	}{
+		{
+			name:     "new settings",
+			main:     "interval: 30s",
+			included: "timeout: 5s",
+			want:     "{interval: 30s, timeout: 5s}",
+		},
+		{
+			name:     "lists are appended",
+			main:     "monitored_services: {http: [{name: a}]}",
+			included: "monitored_services: {http: [{name: b}, {name: c}]}",
+			want:     "monitored_services: {http: [{name: a}, {name: b}, {name: c}]}",
+		},
+		{
+			name:     "merged mappings",
+			main:     "notification_services: {ops: {type: slack}}",
+			included: "notification_services: {dev: {type: exec}}",
+			want:     "notification_services: {ops: {type: slack}, dev: {type: exec}}",
+		},
+		{
+			name:     "nested lists of merged mappings",
+			main:     "escalation_policies: {night: [{after: 0}]}",
+			included: "escalation_policies: {night: [{after: 5m}], day: [{after: 0}]}",
+			want:     "escalation_policies: {night: [{after: 0}, {after: 5m}], day: [{after: 0}]}",
+		},
+		{
+			name:     "duplicate setting",
+			main:     "interval: 30s",
+			included: "interval: 1m",
+			want:     "interval: 30s",
+			problems: []string{"interval is already set in main.yaml:1"},
+		},
+		{
+			name:     "duplicate instance",
+			main:     "notification_services: {ops: {type: slack}}",
+			included: "notification_services: {ops: {type: exec}}",
+			want:     "notification_services: {ops: {type: slack}}",
+			problems: []string{"notification_services.ops is already set in main.yaml:1"},
+		},
+		{
+			name:     "mappings which are not merged",
+			main:     "digest: {window: 5m}",
+			included: "digest: {bypass_severity: critical}",
+			want:     "digest: {window: 5m}",
+			problems: []string{"digest is already set in main.yaml:1"},
+		},
+		{
+			name:     "list and setting",
+			main:     "include: [a.yaml]",
+			included: "include: b.yaml",
+			want:     "include: [a.yaml]",
+			problems: []string{"include is already set in main.yaml:1"},
+		},
+	}
+
+	for _, tt := range tests {
+		f := &Config{files: []configFile{
+			{name: "main.yaml", offset: 0, lines: true},
+			{name: "included.yaml", offset: 10, lines: true},
+		}}
+
+		var main, included yaml.Node
+		if err := yaml.Unmarshal([]byte(tt.main), &main); err != nil {
+			t.Fatalf("%s: could not parse main: %v", tt.name, err)
+		}
+		if err := yaml.Unmarshal([]byte(tt.included), &included); err != nil {
+			t.Fatalf("%s: could not parse included: %v", tt.name, err)
+		}
+		shiftLines(&included, 10)
+
+		f.merge(main.Content[0], included.Content[0], "")
+
+		var got, want interface{}
+		if err := main.Decode(&got); err != nil {
+			t.Fatalf("%s: could not decode merged: %v", tt.name, err)
+		}
+		if err := yaml.Unmarshal([]byte(tt.want), &want); err != nil {
+			t.Fatalf("%s: could not parse want: %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: merged = %v, want %v", tt.name, got, want)
+		}
+
+		var problems []string
+		for _, problem := range f.problems {
+			problems = append(problems, problem.Message)
+			if file, line := f.position(problem.Line); file != "included.yaml" || line != 1 {
+				t.Errorf("%s: problem at %s:%d, want included.yaml:1", tt.name, file, line)
+			}
+		}
+		if !reflect.DeepEqual(problems, tt.problems) {
+			t.Errorf("%s: problems = %q, want %q", tt.name, problems, tt.problems)
+		}
+	}
+}
+
+func TestPosition(t *testing.T) {
+	f := &Config{
+		ConfigFile: "conf.d",
+		files: []configFile{
+			{name: "conf.d/a.yaml", offset: 0, lines: true},
+			{name: "conf.d/b.yaml", offset: 12, lines: true},
+			{name: "conf.d/c.toml", offset: 20},
+			{name: "extra.json", offset: 21, lines: true},
+		},
+	}
+
+	tests := []struct {
+		line     int
+		wantFile string
+		wantLine int
+	}{
+		{line: 1, wantFile: "conf.d/a.yaml", wantLine: 1},
+		{line: 12, wantFile: "conf.d/a.yaml", wantLine: 12},
+		{line: 13, wantFile: "conf.d/b.yaml", wantLine: 1},
+		{line: 20, wantFile: "conf.d/b.yaml", wantLine: 8},
+		// the settings of a toml file have no lines
+		{line: 21, wantFile: "conf.d/c.toml", wantLine: 0},
+		{line: 22, wantFile: "extra.json", wantLine: 1},
+		{line: 0, wantFile: "conf.d", wantLine: 0},
+	}
+
+	for _, tt := range tests {
+		file, line := f.position(tt.line)
+		if file != tt.wantFile || line != tt.wantLine {
+			t.Errorf("position(%d) = %s:%d, want %s:%d", tt.line, file, line, tt.wantFile, tt.wantLine)
+		}
+
+		want := tt.wantFile
+		if tt.wantLine > 0 {
+			want = fmt.Sprintf("%s:%d", tt.wantFile, tt.wantLine)
+		}
+		if got := f.location(tt.line); got != want {
+			t.Errorf("location(%d) = %s, want %s", tt.line, got, want)
+		}
+	}
+}
+
+func TestLoadIncludedFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"main.yaml":         "include: [included/*.yaml, included/*.toml]\ninterval: 30\ntimeout: 10\n",
+		"included/a.yaml":   "# the timeout of the slow endpoints\n\ntimeout: 20\n",
+		"included/b.yaml":   "interval: 60\nnotify_service: slack\n",
+		"included/c.yaml":   "",
+		"included/d.yaml":   "log_level: DEBUG\ntimeout: 5\n",
+		"included/e.toml":   "log_level = \"INFO\"\n\nretry_delay = 2\n",
+		"included/skip.txt": "timeout: 1\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	main := filepath.Join(dir, "main.yaml")
+	f := &Config{ConfigFile: main}
+	if err := f.loadFromConfigFile(); err != nil {
+		t.Fatalf("loadFromConfigFile error = %v", err)
+	}
+
+	if f.NotifyService != "slack" || f.Loglevel != "DEBUG" {
+		t.Errorf("included settings not loaded, notify service %q, log level %q", f.NotifyService, f.Loglevel)
+	}
+
+	// the problems are reported at the line of the included file, naming the line of the main file
+	want := []string{
+		fmt.Sprintf("%s:3: timeout is already set in %s:3", filepath.Join(dir, "included/a.yaml"), main),
+		fmt.Sprintf("%s:1: interval is already set in %s:2", filepath.Join(dir, "included/b.yaml"), main),
+		fmt.Sprintf("%s:2: timeout is already set in %s:3", filepath.Join(dir, "included/d.yaml"), main),
+		fmt.Sprintf("%s: log_level is already set in %s:1", filepath.Join(dir, "included/e.toml"), filepath.Join(dir, "included/d.yaml")),
+	}
+
+	var got []string
+	for _, problem := range f.problems {
+		got = append(got, fmt.Sprintf("%s: %s", f.location(problem.Line), problem.Message))
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("problems = %q, want %q", got, want)
+	}
+}
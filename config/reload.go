@@ -0,0 +1,101 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"github.com/hashicorp/go-hclog"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// watchInterval is how often the config file is checked for changes
+const watchInterval = 2 * time.Second
+
+// Reload loads the configuration again, from the config file and the flags the config was loaded with,
+// and checks it. The logger is kept, its level is only changed by SetLogLevel once the new config is applied.
+func (f *Config) Reload() (*Config, error) {
+	next := &Config{}
+	next.withDefaults()
+
+	flags := flag.NewFlagSet("reload", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+
+	if err := next.getConfig(flags, f.args); err != nil {
+		return nil, fmt.Errorf("could not load configuration: %w", err)
+	}
+
+	if err := next.prepare(); err != nil {
+		return nil, err
+	}
+
+	if err := next.Check(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	next.Logger = f.Logger
+
+	return next, nil
+}
+
+// SetLogLevel sets the level of the logger to the one of the config
+func (f *Config) SetLogLevel() {
+	f.Logger.SetLevel(hclog.LevelFromString(f.Loglevel))
+}
+
+// Watch returns a channel which receives a value every time one of the config files changes, including the files
+// of the config directory and the included files, it never receives anything if no config file is used
+func (f *Config) Watch() <-chan struct{} {
+	changes := make(chan struct{}, 1)
+	if f.ConfigFile == "" {
+		return changes
+	}
+
+	go func() {
+		last, _ := snapshot(f.ConfigFile)
+
+		ticker := time.NewTicker(watchInterval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			current, ok := snapshot(f.ConfigFile)
+			// the file can be missing for a moment while it is replaced
+			if !ok || current == last {
+				continue
+			}
+			last = current
+
+			select {
+			case changes <- struct{}{}:
+			default:
+			}
+		}
+	}()
+
+	return changes
+}
+
+// snapshot returns the names, sizes and modification times of the config files, ok is false if the config file is missing.
+// If the config files can not be listed, the error is returned instead, so the reload reports it.
+func snapshot(location string) (string, bool) {
+	if _, err := os.Stat(location); err != nil {
+		return "", false
+	}
+
+	main, included, err := configFiles(location)
+	if err != nil {
+		return err.Error(), true
+	}
+
+	var out strings.Builder
+	for _, name := range append(main, included...) {
+		info, err := os.Stat(name)
+		if err != nil {
+			continue
+		}
+		fmt.Fprintf(&out, "%s %d %d\n", name, info.Size(), info.ModTime().UnixNano())
+	}
+
+	return out.String(), true
+}
@@ -0,0 +1,126 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// schemaVersion is the JSON Schema draft the schema of the config follows
+const schemaVersion = "http://json-schema.org/draft-07/schema#"
+
+// durationPattern matches the Go durations, like 30s, 5m or 1h30m
+const durationPattern = `^([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$`
+
+// schema is the JSON Schema of a setting of the config
+type schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Minimum              *int               `json:"minimum,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	AnyOf                []*schema          `json:"anyOf,omitempty"`
+	Properties           map[string]*schema `json:"properties,omitempty"`
+	Items                *schema            `json:"items,omitempty"`
+	AdditionalProperties interface{}        `json:"additionalProperties,omitempty"`
+}
+
+// Schema returns the JSON Schema of the config file, generated from the settings of the config
+func Schema() ([]byte, error) {
+	root := schemaOf(reflect.TypeOf(Config{}))
+	root.Schema = schemaVersion
+	root.Title = "go-notify configuration"
+
+	return json.MarshalIndent(root, "", "  ")
+}
+
+// schemaOf returns the schema of the setting type, the structs do not allow any other settings than their own
+func schemaOf(t reflect.Type) *schema {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == reflect.TypeOf(ChannelInstance{}) {
+		return channelSchema()
+	}
+
+	// the durations are either a number of seconds or a Go duration
+	if t == reflect.TypeOf(Duration(0)) {
+		minimum := 0
+		return &schema{AnyOf: []*schema{
+			{Type: "integer", Minimum: &minimum},
+			{Type: "string", Pattern: durationPattern},
+		}}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		s := &schema{Type: "object", Properties: map[string]*schema{}, AdditionalProperties: false}
+		addProperties(s, t)
+		return s
+	case reflect.Slice, reflect.Array:
+		return &schema{Type: "array", Items: schemaOf(t.Elem())}
+	case reflect.Map:
+		return &schema{Type: "object", AdditionalProperties: schemaOf(t.Elem())}
+	case reflect.String:
+		return &schema{Type: "string"}
+	case reflect.Bool:
+		return &schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &schema{Type: "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		minimum := 0
+		return &schema{Type: "integer", Minimum: &minimum}
+	case reflect.Float32, reflect.Float64:
+		return &schema{Type: "number"}
+	default:
+		return &schema{}
+	}
+}
+
+// addProperties adds the settings of the struct to the properties of the schema, including the ones of its inlined structs.
+// The secrets also get their _file setting.
+func addProperties(s *schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("yaml")
+		name := strings.Split(tag, ",")[0]
+
+		if field.PkgPath != "" || name == "-" || field.Type.Kind() == reflect.Interface {
+			continue
+		}
+
+		if strings.Contains(tag, ",inline") {
+			addProperties(s, field.Type)
+			continue
+		}
+
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+
+		s.Properties[name] = schemaOf(field.Type)
+		if secretKeys[name] {
+			s.Properties[name+"_file"] = &schema{Type: "string"}
+		}
+	}
+}
+
+// channelSchema returns the schema of a notification service instance, which can have the settings of every type
+func channelSchema() *schema {
+	s := &schema{
+		Type: "object",
+		Properties: map[string]*schema{
+			"type": {Type: "string", Enum: notifierTypes},
+		},
+		AdditionalProperties: false,
+	}
+
+	services := &NotificationServices{}
+	for _, notifierType := range notifierTypes {
+		addProperties(s, reflect.TypeOf(services.settings(notifierType)).Elem())
+	}
+
+	return s
+}
@@ -0,0 +1,200 @@
+package config
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io"
+	"strings"
+)
+
+// redacted replaces the secrets in the shown configuration
+const redacted = "<redacted>"
+
+// Show writes the effective configuration as YAML with the secrets redacted,
+// and a comment on every setting telling where its value came from
+func (f *Config) Show(w io.Writer) error {
+	shown := *f
+	shown.Logger = nil
+
+	var root yaml.Node
+	if err := root.Encode(&shown); err != nil {
+		return fmt.Errorf("could not marshal Config struct: %w", err)
+	}
+	describe(&root, "", f.sources(), "")
+
+	buff, err := yaml.Marshal(&root)
+	if err != nil {
+		return fmt.Errorf("could not marshal Config struct: %w", err)
+	}
+
+	if _, err := w.Write(buff); err != nil {
+		return fmt.Errorf("could not write config: %w", err)
+	}
+
+	return nil
+}
+
+// sources returns where the settings came from, keyed by their path. The settings that are
+// not in it have their default value, or the value of their parent if it was set by a flag.
+func (f *Config) sources() map[string]string {
+	sources := map[string]string{}
+
+	var walk func(node *yaml.Node, path string)
+	walk = func(node *yaml.Node, path string) {
+		switch node.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				key := node.Content[i].Value
+				if path != "" {
+					key = path + "." + key
+				}
+
+				sources[key] = "file " + f.location(node.Content[i].Line)
+				walk(node.Content[i+1], key)
+			}
+		case yaml.SequenceNode:
+			for i, item := range node.Content {
+				walk(item, fmt.Sprintf("%s[%d]", path, i))
+			}
+		}
+	}
+
+	if f.node != nil && len(f.node.Content) > 0 {
+		walk(f.node.Content[0], "")
+	}
+
+	for i, mon := range f.MonitoredServices.Http {
+		if mon.flag == 0 {
+			continue
+		}
+
+		// the monitor replaced the one of the config file at the same position
+		path := fmt.Sprintf("monitored_services.http[%d]", i)
+		for key := range sources {
+			if strings.HasPrefix(key, path+".") {
+				delete(sources, key)
+			}
+		}
+		sources[path] = "flag -http"
+	}
+
+	for _, o := range f.overrides {
+		path := flagPaths[o.flag]
+
+		// the notification service settings are set on every instance of their type
+		notifierType := strings.SplitN(path, ".", 2)[0]
+		if (&NotificationServices{}).settings(notifierType) == nil {
+			sources[path] = o.source
+			continue
+		}
+
+		for name, instance := range f.Channels {
+			if instance.Type == notifierType {
+				sources["notification_services."+name+strings.TrimPrefix(path, notifierType)] = o.source
+			}
+		}
+	}
+
+	// the settings taken from the global ones came from where the global settings did
+	for i, mon := range f.MonitoredServices.Http {
+		for _, key := range mon.inherited {
+			source, ok := sources[key]
+			if !ok {
+				source = "default"
+			}
+			sources[fmt.Sprintf("monitored_services.http[%d].%s", i, key)] = source
+		}
+	}
+
+	// the global digest settings are the defaults of the digest of every channel instance
+	digest := map[string]string{}
+	for key, source := range sources {
+		if strings.HasPrefix(key, "digest.") {
+			digest[key] = source
+		}
+	}
+
+	for name := range f.Channels {
+		for key, source := range digest {
+			path := "notification_services." + name + "." + key
+			if _, ok := sources[path]; !ok {
+				sources[path] = source
+			}
+		}
+	}
+
+	return sources
+}
+
+// describe redacts the secrets of the node and adds the source of every setting as its comment,
+// inherited is the source of the parent setting if it was not set in the config file
+func describe(node *yaml.Node, path string, sources map[string]string, inherited string) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			describe(child, path, sources, inherited)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			if path != "" {
+				key = path + "." + key
+			}
+
+			value := node.Content[i+1]
+			if secretKeys[node.Content[i].Value] && value.Kind == yaml.ScalarNode && value.Value != "" {
+				value.Value = redacted
+				value.Tag = "!!str"
+			}
+
+			describeValue(value, key, sources, inherited)
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			describeValue(item, fmt.Sprintf("%s[%d]", path, i), sources, inherited)
+		}
+	}
+}
+
+// describeValue adds the source of the setting at the path as the comment of its value,
+// or describes its settings if it has any
+func describeValue(value *yaml.Node, path string, sources map[string]string, inherited string) {
+	source, ok := sources[path]
+	if !ok {
+		source = inherited
+	}
+
+	if value.Kind == yaml.ScalarNode || scalarSequence(value) {
+		if source == "" {
+			source = "default"
+		}
+
+		if value.Kind == yaml.SequenceNode {
+			value.Style = yaml.FlowStyle
+		}
+		value.LineComment = source
+
+		return
+	}
+
+	// the settings of a mapping from the config file can still have their default value
+	if strings.HasPrefix(source, "file ") {
+		source = ""
+	}
+	describe(value, path, sources, source)
+}
+
+// scalarSequence returns true if the node is a sequence of scalar values
+func scalarSequence(node *yaml.Node) bool {
+	if node.Kind != yaml.SequenceNode {
+		return false
+	}
+
+	for _, item := range node.Content {
+		if item.Kind != yaml.ScalarNode {
+			return false
+		}
+	}
+
+	return true
+}
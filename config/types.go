@@ -1,19 +1,56 @@
 package config
 
-import "github.com/hashicorp/go-hclog"
+import (
+	"github.com/hashicorp/go-hclog"
+	"gopkg.in/yaml.v3"
+)
 
 type Config struct {
 	MonitoredServices MonitoredServices `yaml:"monitored_services"`
 	NotifyService     string            `yaml:"notify_service"`
-	Interval          uint64            `yaml:"interval"`
-	Timeout           uint64            `yaml:"timeout"`
+	Interval          Duration          `yaml:"interval"`
+	Timeout           Duration          `yaml:"timeout"`
 	ConfigFile        string            `yaml:"config_file,omitempty"`
 	Loglevel          string            `yaml:"log_level"`
 	LogFileName       string            `yaml:"log_filename"`
 
-	Services NotificationServices `yaml:"notification_services"`
+	// Retries is the number of times a failed health check is repeated before the endpoint is considered down
+	Retries uint64 `yaml:"retries"`
+	// RetryDelay is the time between the repeated health checks
+	RetryDelay Duration `yaml:"retry_delay"`
+
+	// Channels are the named notification service instances, each with its own type and settings
+	Channels map[string]*ChannelInstance `yaml:"notification_services"`
+	// Services holds the default settings of every notifier type, set by the flags. The notifiers read their
+	// settings from it, so every channel instance gets a copy of the config with its own settings in it.
+	Services NotificationServices `yaml:"-"`
+
+	Delivery Delivery `yaml:"delivery"`
+	Policy   Policy   `yaml:"policy"`
+	Digest   Digest   `yaml:"digest"`
+	Api      Api      `yaml:"api"`
+
+	EscalationPolicies map[string][]EscalationTier `yaml:"escalation_policies,omitempty"`
+	Maintenance        []MaintenanceWindow         `yaml:"maintenance,omitempty"`
+	Routes             []Route                     `yaml:"routes,omitempty"`
+
+	// Include are the glob patterns of the config files merged into this one, relative to its directory
+	Include []string `yaml:"include,omitempty"`
 
 	Logger hclog.Logger `yaml:"logger,omitempty"`
+
+	// flagMonitors are the monitors set with the -http flags
+	flagMonitors monitorFlags
+	// overrides are the settings of the environment variables and the explicitly set flags, applied over the config file
+	overrides []override
+	// args are the flags the config was loaded with, used again on reload
+	args []string
+	// node is the parsed config file, or the config files merged together, used to find the lines of the problems
+	node *yaml.Node
+	// files are the config files merged into node
+	files []configFile
+	// problems are the settings of the config file which could not be decoded
+	problems Problems
 }
 
 type MonitoredServices struct {
@@ -21,16 +58,132 @@ type MonitoredServices struct {
 }
 
 type Monitor struct {
+	Name             string `yaml:"name,omitempty"`
 	Endpoint         string `yaml:"endpoint"`
 	ExpectedResponse string `yaml:"expected_response"`
+	// ExpectedStatus is the required status code of the response, any status is accepted if it is not set
+	ExpectedStatus int      `yaml:"expected_status,omitempty"`
+	Severity       string   `yaml:"severity,omitempty"`
+	RepeatEvery    Duration `yaml:"repeat_every,omitempty"`
+	Escalation     string   `yaml:"escalation,omitempty"`
+
+	// Interval, Timeout, Retries and RetryDelay replace the global settings for this monitor,
+	// they are nil until the global settings are set on the monitor if it did not set them
+	Interval   *Duration `yaml:"interval,omitempty"`
+	Timeout    *Duration `yaml:"timeout,omitempty"`
+	Retries    *uint64   `yaml:"retries,omitempty"`
+	RetryDelay *Duration `yaml:"retry_delay,omitempty"`
+
+	Labels map[string]string `yaml:"labels,omitempty"`
+	// DependsOn are the names of the monitors this one can not be reached without
+	DependsOn []string `yaml:"depends_on,omitempty"`
+
+	// flag is the position of the -http flag the monitor was set with, starting from 1, or 0 if it is from the config file
+	flag int
+	// inherited are the keys of the settings taken from the global ones
+	inherited []string
+}
+
+// EscalationTier lists the notification services used once an incident is open for After
+type EscalationTier struct {
+	After  Duration `yaml:"after"`
+	Notify []string `yaml:"notify"`
+}
+
+// Matcher selects monitors by name, label or url, every field that is set has to match.
+// Names and Url can contain * wildcards.
+type Matcher struct {
+	Names  []string          `yaml:"names,omitempty" json:"names,omitempty"`
+	Labels map[string]string `yaml:"labels,omitempty" json:"labels,omitempty"`
+	Url    string            `yaml:"url,omitempty" json:"url,omitempty"`
+}
+
+// Empty returns true if the matcher has no conditions
+func (m Matcher) Empty() bool {
+	return len(m.Names) == 0 && len(m.Labels) == 0 && m.Url == ""
+}
+
+// Route sends the events of the matching monitors to its channels. The routes are checked in order,
+// and the first matching one ends the routing unless Continue is set.
+type Route struct {
+	Name     string   `yaml:"name,omitempty"`
+	Match    Matcher  `yaml:"match,omitempty"`
+	Channels []string `yaml:"channels"`
+	// To replaces the email and sms recipients of the channels for the events of this route
+	To       []string `yaml:"to,omitempty"`
+	Continue bool     `yaml:"continue,omitempty"`
+}
+
+// MaintenanceWindow suppresses the notifications of the matching monitors while it is active.
+// It is either one-time, from Start to End, recurring on a Cron schedule for Duration,
+// or recurring on Weekdays between From and To.
+type MaintenanceWindow struct {
+	Name     string  `yaml:"name"`
+	Match    Matcher `yaml:"match"`
+	Timezone string  `yaml:"timezone,omitempty"`
+
+	Start string `yaml:"start,omitempty"`
+	End   string `yaml:"end,omitempty"`
+
+	Cron     string   `yaml:"cron,omitempty"`
+	Duration Duration `yaml:"duration,omitempty"`
+
+	Weekdays []string `yaml:"weekdays,omitempty"`
+	From     string   `yaml:"from,omitempty"`
+	To       string   `yaml:"to,omitempty"`
+}
+
+type Delivery struct {
+	Retries        uint64   `yaml:"retries"`
+	InitialBackoff Duration `yaml:"initial_backoff"`
+	MaxBackoff     Duration `yaml:"max_backoff"`
+	SpoolDir       string   `yaml:"spool_dir"`
+}
+
+type Policy struct {
+	RenotifyInterval Duration `yaml:"renotify_interval"`
+	RateLimit        uint64   `yaml:"rate_limit"`
+	RateWindow       Duration `yaml:"rate_window"`
+}
+
+type Digest struct {
+	Window         Duration `yaml:"window"`
+	BypassSeverity string   `yaml:"bypass_severity"`
+}
+
+// Api configures the embedded HTTP server used to acknowledge incidents
+type Api struct {
+	// Listen is the address of the server, the server is disabled if it is empty
+	Listen string `yaml:"listen"`
+	// ExternalUrl is the address of the server used in the acknowledge links
+	ExternalUrl string `yaml:"external_url"`
+	// Secret is the key used to sign the acknowledge links
+	Secret string `yaml:"secret"`
+	// Token is the bearer token required by the JSON API
+	Token string `yaml:"token"`
+}
+
+// Channel holds the settings shared by all notification services
+type Channel struct {
+	RepeatEvery Duration `yaml:"repeat_every,omitempty"`
+	// Digest is the digest of the channel, its settings default to the ones of the digest of the config
+	Digest Digest `yaml:"digest,omitempty"`
 }
 
 type NotificationServices struct {
-	Email Email `yaml:"email"`
-	Slack Slack `yaml:"slack"`
+	Email  Email  `yaml:"email"`
+	Slack  Slack  `yaml:"slack"`
+	Matrix Matrix `yaml:"matrix"`
+	Ntfy   Ntfy   `yaml:"ntfy"`
+	Gotify Gotify `yaml:"gotify"`
+	Sms    Sms    `yaml:"sms"`
+	Exec   Exec   `yaml:"exec"`
+	Syslog Syslog `yaml:"syslog"`
 }
 
 type Email struct {
+	Channel `yaml:",inline"`
+
 	To         arrayFlags `yaml:"to"`
 	Cc         arrayFlags `yaml:"cc"`
 	Bcc        arrayFlags `yaml:"bcc"`
@@ -45,5 +198,122 @@ type Email struct {
 }
 
 type Slack struct {
+	Channel `yaml:",inline"`
+
 	Webhook string `yaml:"webhook"`
 }
+
+type Matrix struct {
+	Channel `yaml:",inline"`
+
+	Homeserver  string `yaml:"homeserver"`
+	AccessToken string `yaml:"access_token"`
+	RoomID      string `yaml:"room_id"`
+}
+
+type Ntfy struct {
+	Channel `yaml:",inline"`
+
+	Server   string   `yaml:"server"`
+	Topic    string   `yaml:"topic"`
+	Token    string   `yaml:"token"`
+	Username string   `yaml:"username"`
+	Password string   `yaml:"password"`
+	Tags     []string `yaml:"tags"`
+}
+
+type Gotify struct {
+	Channel `yaml:",inline"`
+
+	Server   string   `yaml:"server"`
+	AppToken string   `yaml:"app_token"`
+	Tags     []string `yaml:"tags"`
+}
+
+type Sms struct {
+	Channel `yaml:",inline"`
+
+	BaseUrl    string     `yaml:"base_url"`
+	AccountSid string     `yaml:"account_sid"`
+	AuthToken  string     `yaml:"auth_token"`
+	From       string     `yaml:"from"`
+	To         arrayFlags `yaml:"to"`
+	MaxLength  int        `yaml:"max_length"`
+}
+
+type Exec struct {
+	Channel `yaml:",inline"`
+
+	Command string   `yaml:"command"`
+	Args    []string `yaml:"args"`
+	Timeout Duration `yaml:"timeout"`
+}
+
+type Syslog struct {
+	Channel `yaml:",inline"`
+
+	Network            string `yaml:"network"`
+	Address            string `yaml:"address"`
+	Facility           string `yaml:"facility"`
+	AppName            string `yaml:"app_name"`
+	Hostname           string `yaml:"hostname"`
+	CAFile             string `yaml:"ca_file"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"`
+}
+
+// Channel returns the common settings of the notifier type
+func (n NotificationServices) Channel(notifierType string) Channel {
+	if channel := n.channel(notifierType); channel != nil {
+		return *channel
+	}
+
+	return Channel{}
+}
+
+// channel returns the common settings of the notifier type, or nil if the type is unknown
+func (n *NotificationServices) channel(notifierType string) *Channel {
+	switch notifierType {
+	case "email":
+		return &n.Email.Channel
+	case "slack":
+		return &n.Slack.Channel
+	case "matrix":
+		return &n.Matrix.Channel
+	case "ntfy":
+		return &n.Ntfy.Channel
+	case "gotify":
+		return &n.Gotify.Channel
+	case "sms":
+		return &n.Sms.Channel
+	case "exec":
+		return &n.Exec.Channel
+	case "syslog":
+		return &n.Syslog.Channel
+	default:
+		return nil
+	}
+}
+
+// settings returns the settings of the notifier type, or nil if the type is unknown
+func (n *NotificationServices) settings(notifierType string) interface{} {
+	switch notifierType {
+	case "email":
+		return &n.Email
+	case "slack":
+		return &n.Slack
+	case "matrix":
+		return &n.Matrix
+	case "ntfy":
+		return &n.Ntfy
+	case "gotify":
+		return &n.Gotify
+	case "sms":
+		return &n.Sms
+	case "exec":
+		return &n.Exec
+	case "syslog":
+		return &n.Syslog
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,613 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"github.com/ZeljkoBenovic/go-notify/notify/silence/schedule"
+	"gopkg.in/yaml.v3"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Problem is a single invalid setting of the configuration
+type Problem struct {
+	// Path is the YAML path of the setting, e.g. monitored_services.http[1].endpoint
+	Path string
+	// File is the config file of the setting, empty if it is not set in a file
+	File string
+	// Line is the line of the setting in the config file, 0 if it is not set in the file or its lines are not known
+	Line    int
+	Message string
+}
+
+func (p Problem) String() string {
+	var out strings.Builder
+
+	if p.File != "" {
+		out.WriteString(p.File)
+		if p.Line > 0 {
+			fmt.Fprintf(&out, ":%d", p.Line)
+		}
+		out.WriteString(": ")
+	}
+
+	if p.Path != "" {
+		out.WriteString(p.Path + ": ")
+	}
+	out.WriteString(p.Message)
+
+	return out.String()
+}
+
+// Problems are all the problems found in the configuration
+type Problems []Problem
+
+func (p Problems) Error() string {
+	messages := make([]string, 0, len(p))
+	for _, problem := range p {
+		messages = append(messages, problem.String())
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// typeErrorLine matches the line number yaml adds to its type errors
+var typeErrorLine = regexp.MustCompile(`^line (\d+): (.*)$`)
+
+// addTypeError records the fields of the config file that could not be decoded, so they are reported with the other problems
+func (f *Config) addTypeError(err *yaml.TypeError) {
+	for _, message := range err.Errors {
+		problem := Problem{Message: message}
+
+		if match := typeErrorLine.FindStringSubmatch(message); match != nil {
+			problem.Line, _ = strconv.Atoi(match[1])
+			problem.Message = match[2]
+			problem.Path = pathAt(f.node, problem.Line)
+		}
+
+		f.problems = append(f.problems, problem)
+	}
+}
+
+// Validate loads the configuration from the flags in args, registered on the provided flag set, and the config file,
+// and returns all problems found in it. The error is set only if the configuration could not be loaded at all.
+func Validate(flags *flag.FlagSet, args []string) (Problems, error) {
+	config := &Config{}
+	config.withDefaults()
+
+	if err := config.getConfig(flags, args); err != nil {
+		return nil, fmt.Errorf("could not load configuration: %w", err)
+	}
+
+	if err := config.prepare(); err != nil {
+		return nil, err
+	}
+
+	return config.validate(true), nil
+}
+
+// Check returns all problems of the configuration as a single error
+func (f *Config) Check() error {
+	if problems := f.validate(true); len(problems) > 0 {
+		return problems
+	}
+
+	return nil
+}
+
+// CheckMonitors returns the problems of the monitor settings as a single error,
+// leaving out the settings only used to send notifications
+func (f *Config) CheckMonitors() error {
+	if problems := f.validate(false); len(problems) > 0 {
+		return problems
+	}
+
+	return nil
+}
+
+// validator collects the problems, looking up their lines in the config file
+type validator struct {
+	root     *yaml.Node
+	problems Problems
+}
+
+func (v *validator) add(path string, format string, args ...interface{}) {
+	v.problems = append(v.problems, Problem{
+		Path:    path,
+		Line:    lineOf(v.root, path),
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// validate checks the configuration and returns every problem found,
+// the settings used to send notifications are only checked if notifications is set
+func (f *Config) validate(notifications bool) Problems {
+	v := &validator{root: f.node}
+	v.problems = append(v.problems, f.problems...)
+
+	if f.Interval == 0 {
+		v.add("interval", "interval must be greater than zero")
+	}
+
+	// a check without a timeout could wait for the endpoint forever
+	if f.Timeout == 0 {
+		v.add("timeout", "timeout must be greater than zero")
+	}
+
+	f.checkMonitors(v)
+	f.checkDependencies(v)
+
+	if notifications {
+		f.checkNotifications(v)
+	}
+
+	// the maps of the config are checked in random order, so the problems are sorted to be reported in the order of the file
+	sort.SliceStable(v.problems, func(i, j int) bool {
+		if v.problems[i].Line != v.problems[j].Line {
+			return v.problems[i].Line < v.problems[j].Line
+		}
+		return v.problems[i].Path < v.problems[j].Path
+	})
+
+	// the lines are numbered over all the merged config files
+	for i, problem := range v.problems {
+		if problem.Line > 0 {
+			v.problems[i].File, v.problems[i].Line = f.position(problem.Line)
+		}
+	}
+
+	return v.problems
+}
+
+// checkNotifications checks the settings used to send the notifications
+func (f *Config) checkNotifications(v *validator) {
+	if f.NotifyService == "" {
+		v.add("notify_service", "notify service is not set")
+	} else if _, ok := f.Channels[f.NotifyService]; !ok {
+		v.add("notify_service", "undefined notification service %s", f.NotifyService)
+	}
+
+	if f.Policy.RateLimit > 0 && f.Policy.RateWindow == 0 {
+		v.add("policy.rate_window", "rate window must be greater than zero if the rate limit is set")
+	}
+
+	if f.Api.Listen != "" {
+		if f.Api.Secret == "" {
+			v.add("api.secret", "secret must be set if the api is enabled")
+		}
+		if f.Api.Token == "" {
+			v.add("api.token", "token must be set if the api is enabled")
+		}
+	}
+
+	if !validSeverity(f.Digest.BypassSeverity, true) {
+		v.add("digest.bypass_severity", "unknown severity %q", f.Digest.BypassSeverity)
+	}
+
+	f.checkEscalationPolicies(v)
+	f.checkMaintenance(v)
+	f.checkRoutes(v)
+	f.checkChannels(v)
+}
+
+// checkMonitors checks that every monitor has a valid url and expected response, and a unique name
+func (f *Config) checkMonitors(v *validator) {
+	if len(f.MonitoredServices.Http) == 0 {
+		v.add("monitored_services.http", "no monitors defined")
+	}
+
+	names := map[string]string{}
+
+	for i, mon := range f.MonitoredServices.Http {
+		path := fmt.Sprintf("monitored_services.http[%d]", i)
+		if mon.flag > 0 {
+			path = fmt.Sprintf("-http[%d]", mon.flag-1)
+		}
+
+		if mon.Endpoint == "" {
+			v.add(path+".endpoint", "endpoint is not set")
+		} else if u, err := url.Parse(mon.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			v.add(path+".endpoint", "invalid url %q, expected an http or https url", mon.Endpoint)
+		}
+
+		if mon.ExpectedResponse == "" && mon.ExpectedStatus == 0 {
+			v.add(path+".expected_response", "expected response or expected status must be set")
+		}
+
+		if mon.ExpectedStatus != 0 && (mon.ExpectedStatus < 100 || mon.ExpectedStatus > 599) {
+			v.add(path+".expected_status", "invalid status code %d", mon.ExpectedStatus)
+		}
+
+		if !validSeverity(mon.Severity, false) {
+			v.add(path+".severity", "unknown severity %q", mon.Severity)
+		}
+
+		// the zero global settings are reported on their own
+		if mon.Interval != nil && *mon.Interval == 0 && f.Interval != 0 {
+			v.add(path+".interval", "interval must be greater than zero")
+		}
+		if mon.Timeout != nil && *mon.Timeout == 0 && f.Timeout != 0 {
+			v.add(path+".timeout", "timeout must be greater than zero")
+		}
+
+		if first, ok := names[mon.Name]; ok {
+			v.add(path+".name", "monitor name %q is already used by %s", mon.Name, first)
+			continue
+		}
+
+		// the monitors of the config file are told apart by their file, as they can come from several ones
+		names[mon.Name] = path
+		if mon.flag == 0 {
+			names[mon.Name] = fmt.Sprintf("%s in %s", path, f.location(lineOf(v.root, path)))
+		}
+	}
+}
+
+// checkChannels checks that the used notification services are defined, have a known type and the settings their type requires
+func (f *Config) checkChannels(v *validator) {
+	for name, channel := range f.Channels {
+		if channel.NotificationServices.settings(channel.Type) == nil {
+			v.add("notification_services."+name+".type", "unknown type %q", channel.Type)
+		}
+
+		// a command without a timeout could block the notifications forever, even if the instance is not used yet
+		if channel.Type == "exec" && channel.Exec.Timeout == 0 {
+			v.add("notification_services."+name+".timeout", "timeout must be greater than zero")
+		}
+	}
+
+	checked := map[string]bool{}
+
+	for _, name := range f.usedChannels() {
+		if checked[name] {
+			continue
+		}
+		checked[name] = true
+
+		// the undefined ones are reported where they are used
+		channel, ok := f.Channels[name]
+		if !ok {
+			continue
+		}
+
+		path := "notification_services." + name
+		required := func(value string, key string) {
+			if value == "" {
+				v.add(path+"."+key, "%s is not set", key)
+			}
+		}
+
+		if severity := channel.Settings().Digest.BypassSeverity; severity != f.Digest.BypassSeverity && !validSeverity(severity, true) {
+			v.add(path+".digest.bypass_severity", "unknown severity %q", severity)
+		}
+
+		switch channel.Type {
+		case "email":
+			// email settings are only required if email is used to send notifications
+			email := channel.Email
+
+			if len(email.To) == 0 || email.To[0] == "" {
+				v.add(path+".to", "email TO field not defined")
+			}
+
+			if email.UseAuth && email.AuthPass == "" {
+				v.add(path+".auth_pass", "smtp auth password not provided, set auth_pass or auth_pass_file")
+			}
+		case "slack":
+			required(channel.Slack.Webhook, "webhook")
+		case "matrix":
+			required(channel.Matrix.Homeserver, "homeserver")
+			required(channel.Matrix.AccessToken, "access_token")
+			required(channel.Matrix.RoomID, "room_id")
+		case "ntfy":
+			required(channel.Ntfy.Server, "server")
+			required(channel.Ntfy.Topic, "topic")
+		case "gotify":
+			required(channel.Gotify.Server, "server")
+			required(channel.Gotify.AppToken, "app_token")
+		case "sms":
+			required(channel.Sms.AccountSid, "account_sid")
+			required(channel.Sms.AuthToken, "auth_token")
+			required(channel.Sms.From, "from")
+			if len(channel.Sms.To) == 0 {
+				v.add(path+".to", "to is not set")
+			}
+		case "exec":
+			required(channel.Exec.Command, "command")
+		}
+	}
+}
+
+// checkEscalationPolicies checks that the policies are valid and that the monitors use existing ones
+func (f *Config) checkEscalationPolicies(v *validator) {
+	for name, tiers := range f.EscalationPolicies {
+		path := "escalation_policies." + name
+
+		if len(tiers) == 0 {
+			v.add(path, "escalation policy has no tiers")
+		}
+
+		for i, tier := range tiers {
+			if len(tier.Notify) == 0 {
+				v.add(fmt.Sprintf("%s[%d].notify", path, i), "tier has no notification services")
+			}
+
+			for j, channel := range tier.Notify {
+				if _, ok := f.Channels[channel]; !ok {
+					v.add(fmt.Sprintf("%s[%d].notify[%d]", path, i, j), "undefined notification service %s", channel)
+				}
+			}
+
+			if i > 0 && tier.After < tiers[i-1].After {
+				v.add(fmt.Sprintf("%s[%d].after", path, i), "tiers must be ordered by the after value")
+			}
+		}
+	}
+
+	for i, mon := range f.MonitoredServices.Http {
+		if mon.Escalation == "" {
+			continue
+		}
+
+		if _, ok := f.EscalationPolicies[mon.Escalation]; !ok {
+			v.add(fmt.Sprintf("monitored_services.http[%d].escalation", i), "unknown escalation policy %s", mon.Escalation)
+		}
+	}
+}
+
+// checkMaintenance checks that every maintenance window selects monitors and has exactly one valid schedule
+func (f *Config) checkMaintenance(v *validator) {
+	for i, window := range f.Maintenance {
+		path := fmt.Sprintf("maintenance[%d]", i)
+
+		if window.Name == "" {
+			v.add(path+".name", "maintenance window has no name")
+		}
+
+		if window.Match.Empty() {
+			v.add(path+".match", "maintenance window does not match any monitors")
+		}
+
+		location := time.Local
+		if window.Timezone != "" {
+			var err error
+			if location, err = time.LoadLocation(window.Timezone); err != nil {
+				v.add(path+".timezone", "unknown time zone %q", window.Timezone)
+				location = time.Local
+			}
+		}
+
+		// the schedules are parsed like the silences parse them, so the windows accepted here also run
+		schedules := 0
+		if window.Start != "" || window.End != "" {
+			schedules++
+			start, startErr := schedule.ParseTime(window.Start, location)
+			if startErr != nil {
+				v.add(path+".start", "invalid time %q, expected RFC 3339 or \"2006-01-02 15:04\"", window.Start)
+			}
+			end, endErr := schedule.ParseTime(window.End, location)
+			if endErr != nil {
+				v.add(path+".end", "invalid time %q, expected RFC 3339 or \"2006-01-02 15:04\"", window.End)
+			}
+			if startErr == nil && endErr == nil && !end.After(start) {
+				v.add(path+".end", "end must be after start")
+			}
+		}
+
+		if window.Cron != "" {
+			schedules++
+			if _, err := schedule.ParseCron(window.Cron); err != nil {
+				v.add(path+".cron", "invalid cron schedule: %s", err)
+			}
+			if window.Duration == 0 {
+				v.add(path+".duration", "duration must be greater than zero for the cron schedule")
+			}
+		}
+
+		if window.From != "" || window.To != "" {
+			schedules++
+			from, fromErr := schedule.ParseClock(window.From)
+			if fromErr != nil {
+				v.add(path+".from", "invalid time of day %q, expected \"15:04\"", window.From)
+			}
+			to, toErr := schedule.ParseClock(window.To)
+			if toErr != nil {
+				v.add(path+".to", "invalid time of day %q, expected \"15:04\"", window.To)
+			}
+			if fromErr == nil && toErr == nil && from == to {
+				v.add(path+".to", "from and to must differ, the window would always be active")
+			}
+		}
+
+		for j, day := range window.Weekdays {
+			if _, err := schedule.ParseWeekday(day); err != nil {
+				v.add(fmt.Sprintf("%s.weekdays[%d]", path, j), "%s", err)
+			}
+		}
+
+		if schedules != 1 {
+			v.add(path, "maintenance window needs exactly one of start/end, cron or from/to")
+		}
+	}
+}
+
+// checkDependencies checks that the monitors depend only on existing monitors, without any cycles
+func (f *Config) checkDependencies(v *validator) {
+	parents := map[string][]string{}
+	index := map[string]int{}
+	for i, mon := range f.MonitoredServices.Http {
+		parents[mon.Name] = mon.DependsOn
+		index[mon.Name] = i
+	}
+
+	for i, mon := range f.MonitoredServices.Http {
+		for j, parent := range mon.DependsOn {
+			if _, ok := parents[parent]; !ok {
+				v.add(fmt.Sprintf("monitored_services.http[%d].depends_on[%d]", i, j), "unknown monitor %s", parent)
+			}
+		}
+	}
+
+	// walk up the parents of every monitor, a monitor that is reached again is part of a cycle
+	var visit func(name string, path map[string]bool) bool
+	visit = func(name string, path map[string]bool) bool {
+		if path[name] {
+			return true
+		}
+		path[name] = true
+		defer delete(path, name)
+
+		for _, parent := range parents[name] {
+			if visit(parent, path) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	for i, mon := range f.MonitoredServices.Http {
+		if index[mon.Name] == i && visit(mon.Name, map[string]bool{}) {
+			v.add(fmt.Sprintf("monitored_services.http[%d].depends_on", i), "dependencies of monitor %s form a cycle", mon.Name)
+		}
+	}
+}
+
+// checkRoutes checks that every route has defined channels, and that the routes with their own recipients are named
+func (f *Config) checkRoutes(v *validator) {
+	names := map[string]bool{}
+
+	for i, route := range f.Routes {
+		path := fmt.Sprintf("routes[%d]", i)
+
+		if len(route.Channels) == 0 {
+			v.add(path+".channels", "route has no channels")
+		}
+
+		for j, channel := range route.Channels {
+			if _, ok := f.Channels[channel]; !ok {
+				v.add(fmt.Sprintf("%s.channels[%d]", path, j), "undefined notification service %s", channel)
+			}
+		}
+
+		if route.Name == "" {
+			if len(route.To) > 0 {
+				v.add(path+".name", "route needs a name to set its own recipients")
+			}
+			continue
+		}
+
+		if names[route.Name] {
+			v.add(path+".name", "route %s is defined more than once", route.Name)
+		}
+		names[route.Name] = true
+	}
+}
+
+// validSeverity returns true for the known severities, empty is only valid if allowed
+func validSeverity(severity string, allowEmpty bool) bool {
+	switch severity {
+	case "info", "warning", "critical":
+		return true
+	case "":
+		return allowEmpty
+	}
+
+	return false
+}
+
+// lineOf returns the line of the setting at the path in the config file. If the setting is not in the file,
+// the line of its closest parent is returned, or 0 if none of them is.
+func lineOf(root *yaml.Node, path string) int {
+	if root == nil || len(root.Content) == 0 {
+		return 0
+	}
+
+	node, line := root.Content[0], 0
+
+	for _, segment := range strings.Split(path, ".") {
+		key, indexes := segment, []int(nil)
+		if open := strings.Index(segment, "["); open >= 0 {
+			key = segment[:open]
+			for _, index := range strings.Split(strings.Trim(segment[open:], "[]"), "][") {
+				i, _ := strconv.Atoi(index)
+				indexes = append(indexes, i)
+			}
+		}
+
+		value, keyLine := mappingValue(node, key)
+		if value == nil {
+			return line
+		}
+		node, line = value, keyLine
+
+		for _, i := range indexes {
+			if node.Kind != yaml.SequenceNode || i >= len(node.Content) {
+				return line
+			}
+			node, line = node.Content[i], node.Content[i].Line
+		}
+	}
+
+	return line
+}
+
+// pathAt returns the path of the setting on the line of the config file, or an empty path if there is none
+func pathAt(root *yaml.Node, line int) string {
+	if root == nil || len(root.Content) == 0 {
+		return ""
+	}
+
+	var walk func(node *yaml.Node, path string) string
+	walk = func(node *yaml.Node, path string) string {
+		switch node.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				key := node.Content[i].Value
+				if path != "" {
+					key = path + "." + key
+				}
+
+				if found := walk(node.Content[i+1], key); found != "" {
+					return found
+				}
+				if node.Content[i].Line == line {
+					return key
+				}
+			}
+		case yaml.SequenceNode:
+			for i, item := range node.Content {
+				if found := walk(item, fmt.Sprintf("%s[%d]", path, i)); found != "" {
+					return found
+				}
+			}
+		case yaml.ScalarNode:
+			if node.Line == line {
+				return path
+			}
+		}
+
+		return ""
+	}
+
+	return walk(root.Content[0], "")
+}
+
+// mappingValue returns the value of the key in the mapping node and the line of the key
+func mappingValue(node *yaml.Node, key string) (*yaml.Node, int) {
+	if node.Kind != yaml.MappingNode {
+		return nil, 0
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1], node.Content[i].Line
+		}
+	}
+
+	return nil, 0
+}
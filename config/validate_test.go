@@ -0,0 +1,95 @@
+package config
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// validateFile returns the paths of the problems of the config file with the content
+func validateFile(t *testing.T, content string) []string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+
+	problems, err := Validate(flags, []string{"-config", path})
+	if err != nil {
+		t.Fatalf("Validate error = %v", err)
+	}
+
+	var paths []string
+	for _, problem := range problems {
+		paths = append(paths, problem.Path)
+	}
+
+	return paths
+}
+
+func TestValidateMaintenance(t *testing.T) {
+	const base = `
+monitored_services:
+  http:
+    - name: api
+      endpoint: http://localhost/health
+      expected_response: ok
+notify_service: slack
+notification_services:
+  slack:
+    webhook: https://hooks.example.com/x
+maintenance:
+  - name: window
+    match: {names: [api]}
+`
+
+	tests := []struct {
+		name   string
+		window string
+		want   []string
+	}{
+		{name: "one-time", window: `    start: "2024-01-01 02:00"
+    end: "2024-01-01 04:00"`},
+		{name: "cron", window: `    cron: "0 2 * * 1-5"
+    duration: 2h`},
+		{name: "weekly", window: `    from: "22:00"
+    to: "02:00"
+    weekdays: [sat, Sunday]`},
+		{name: "end before start", window: `    start: "2024-01-01 04:00"
+    end: "2024-01-01 02:00"`, want: []string{"maintenance[0].end"}},
+		{name: "end at start", window: `    start: 2024-01-01T04:00:00Z
+    end: 2024-01-01T04:00:00Z`, want: []string{"maintenance[0].end"}},
+		{name: "invalid start", window: `    start: tomorrow
+    end: "2024-01-01 02:00"`, want: []string{"maintenance[0].start"}},
+		{name: "cron out of range", window: `    cron: "99 * * * *"
+    duration: 1h`, want: []string{"maintenance[0].cron"}},
+		{name: "cron with invalid step", window: `    cron: "*/0 * * * *"
+    duration: 1h`, want: []string{"maintenance[0].cron"}},
+		{name: "cron without duration", window: `    cron: "0 2 * * *"`, want: []string{"maintenance[0].duration"}},
+		{name: "unknown weekday", window: `    from: "01:00"
+    to: "02:00"
+    weekdays: [mon, someday]`, want: []string{"maintenance[0].weekdays[1]"}},
+		{name: "from equals to", window: `    from: "01:00"
+    to: "01:00"`, want: []string{"maintenance[0].to"}},
+		{name: "invalid time of day", window: `    from: "25:00"
+    to: "01:00"`, want: []string{"maintenance[0].from"}},
+		{name: "two schedules", window: `    cron: "0 2 * * *"
+    duration: 1h
+    from: "01:00"
+    to: "02:00"`, want: []string{"maintenance[0]"}},
+	}
+
+	for _, tt := range tests {
+		got := validateFile(t, base+tt.window+"\n")
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: problems at %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -3,6 +3,7 @@ module github.com/ZeljkoBenovic/go-notify
 go 1.17
 
 require (
+	github.com/BurntSushi/toml v1.2.1
 	github.com/hashicorp/go-hclog v1.2.1
 	gopkg.in/gomail.v2 v2.0.0-20160411212932-81ebce5c23df
 	gopkg.in/yaml.v3 v3.0.1
@@ -1,38 +1,148 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
+	"github.com/ZeljkoBenovic/go-notify/api"
 	"github.com/ZeljkoBenovic/go-notify/config"
 	"github.com/ZeljkoBenovic/go-notify/monitor"
+	monitorCommon "github.com/ZeljkoBenovic/go-notify/monitor/common"
 	"github.com/ZeljkoBenovic/go-notify/notify"
+	"github.com/ZeljkoBenovic/go-notify/notify/incident"
+	"github.com/ZeljkoBenovic/go-notify/notify/silence"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 )
 
 //TODO: Install service flag
 
 func main() {
+	// run is the default command, also when go-notify is started without any arguments
+	name, args := "run", os.Args[1:]
+
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+
+	// help <command> prints the help of the command
+	if name == "help" {
+		if len(args) == 0 {
+			usage()
+			return
+		}
+		name, args = args[0], []string{"-h"}
+	}
+
+	cmd, ok := findCommand(name)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %s\n\n", name)
+		usage()
+		os.Exit(2)
+	}
+
+	err := cmd.run(cmd.flagSet(), args)
+
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+	case errors.Is(err, errUnhealthy):
+		fmt.Println(err.Error())
+		os.Exit(2)
+	default:
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+}
+
+// runDaemon runs the health checks on every interval and sends the notifications, reloading the config on changes
+func runDaemon(flags *flag.FlagSet, args []string) error {
 	// get conf
-	conf, err := config.NewConfig()
+	conf, err := config.NewConfig(flags, args)
 	if err != nil {
-		fmt.Println("could not create conf error=", err.Error())
-		os.Exit(1)
+		return err
 	}
 
 	conf.Logger.Info("Config successfully initialized.")
 
+	// the open incidents and the silences are shared by the notifiers and the api
+	registry := incident.NewRegistry()
+	silences := silence.NewRegistry()
+
+	if conf.Api.Listen != "" {
+		go func() {
+			if err := api.NewServer(registry, silences, conf).ListenAndServe(); err != nil {
+				conf.Logger.Error("Api server stopped", "error", err.Error())
+			}
+		}()
+	}
+
 	// setup notifier instance
-	notifier, err := notify.NewNotifier(conf)
+	notifier, err := notify.NewNotifier(conf, registry, silences)
 	if err != nil {
-		conf.Logger.Error("Could not set up notifier service", "error", err.Error())
+		return fmt.Errorf("could not set up notifier service: %w", err)
 	}
 
 	// set and run monitor
-	newMon, monErr := monitor.NewMonitor(conf)
-	if monErr != nil {
-		conf.Logger.Error("Could not set up a new sender instance")
+	newMon, err := monitor.NewMonitor(conf)
+	if err != nil {
+		return fmt.Errorf("could not set up monitor: %w", err)
 	}
 
 	newMon.SetNotifier(notifier)
-	newMon.Run()
 
+	// reload the config on SIGHUP and whenever the config file changes
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	changes := conf.Watch()
+
+	// every endpoint is checked on its own interval in the background
+	newMon.Start()
+
+	for {
+		select {
+		case <-hangup:
+			conf.Logger.Info("SIGHUP received, reloading config")
+			conf = reload(conf, newMon, notifier)
+		case <-changes:
+			conf.Logger.Info("Config file changed, reloading config", "file", conf.ConfigFile)
+			conf = reload(conf, newMon, notifier)
+		}
+	}
+}
+
+// reload applies the new config to the notifier and monitor and returns it,
+// the current config is kept and returned if the new one can not be loaded
+func reload(conf *config.Config, mon monitorCommon.IMonitor, notifier *notify.Notifier) *config.Config {
+	next, err := conf.Reload()
+	if err != nil {
+		conf.Logger.Error("Could not reload config, keeping the current one", "error", err.Error())
+		return conf
+	}
+
+	// the api server and the log file are set up only once
+	if next.Api != conf.Api {
+		conf.Logger.Warn("Api settings changed, restart to apply them")
+		next.Api = conf.Api
+	}
+	if next.LogFileName != conf.LogFileName {
+		conf.Logger.Warn("Log file changed, restart to apply it")
+	}
+
+	if err := notifier.Reload(next); err != nil {
+		conf.Logger.Error("Could not reload notifier, keeping the current config", "error", err.Error())
+		return conf
+	}
+
+	if err := mon.Reload(next); err != nil {
+		conf.Logger.Error("Could not reload monitors, keeping the current config", "error", err.Error())
+		return conf
+	}
+
+	// the log level is only changed once the new config is in use
+	next.SetLogLevel()
+	next.Logger.Info("Config reloaded", "monitors", len(next.MonitoredServices.Http), "channels", len(next.Channels))
+
+	return next
 }
@@ -6,12 +6,20 @@ import (
 )
 
 type IMonitor interface {
-	// Run runs the health check against the provided endpoints and sends notifications
+	// Run runs the health check of every endpoint once and sends notifications
 	Run() IMonitor
+	// Check runs the health check of every endpoint once without sending notifications
+	Check() IMonitor
+	// Start runs the health check of every endpoint on its own interval in the background and sends notifications
+	Start()
+	// Status returns the state of every endpoint after the last health check
+	Status() []common.Endpoint
 	// RunMock will not send the notifications, used for testing
 	RunMock()
 	// SetNotifier takes in the notifier interface that monitor will use to send notifications
 	SetNotifier(notifier common.INotifier)
+	// Reload applies the monitors of the new config, keeping the state of the ones that did not change
+	Reload(config *config.Config) error
 }
 
 type MonitorFactory func(config *config.Config) (IMonitor, error)
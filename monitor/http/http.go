@@ -19,124 +19,499 @@ type Url string
 type HttpMonitor struct {
 	Http []HttpEndpoints
 
-	Timeout uint64
-	Logger  hclog.Logger
+	Logger hclog.Logger
 
 	Sender notifyCommon.INotifier
+
+	// index maps the monitor names to their position in Http
+	index map[string]int
+
+	// mux guards the endpoints, which are checked by their own schedulers once the monitor is started
+	mux sync.Mutex
+	// schedulers are the schedulers of the endpoints by their name
+	schedulers map[string]*scheduler
+	// finished is signalled when a check finished, so its logs and notifications are handled
+	finished chan struct{}
+}
+
+// scheduler runs the health checks of a single endpoint on its own interval
+type scheduler struct {
+	// wake makes the scheduler pick up the interval of a reloaded endpoint
+	wake chan struct{}
+	stop chan struct{}
+}
+
+// response is the outcome of a health check request
+type response struct {
+	body   string
+	status int
+	err    error
 }
 
 type HttpEndpoints struct {
+	Name         string
 	Url          string
+	Severity     string
 	Error        error
 	SearchString map[Url]string
 	Result       map[Url]string
 	Healthy      map[Url]bool
+	// ExpectedStatus is the required status code of the response, any status is accepted if it is 0
+	ExpectedStatus int
+	StatusCode     int
+	DependsOn      []string
+	// DownSince is the time of the first failed check of the current outage
+	DownSince time.Time
+	// Downtime is the duration of the outage, set only in the run in which the endpoint recovered
+	Downtime time.Duration
+
+	Interval time.Duration
+	Timeout  time.Duration
+	// Retries is the number of times a failed check is repeated, RetryDelay apart, before the endpoint is considered down
+	Retries    uint64
+	RetryDelay time.Duration
+	// Failures is the number of failed checks which are being retried
+	Failures uint64
+	// LastCheck is the time of the last health check of the endpoint
+	LastCheck time.Time
+	// Checked is set if the health of the endpoint was updated in the last run
+	Checked bool
+	// Pending is set once a scheduled check finished, until its logs and notifications are handled
+	Pending bool
 }
 
 //MonitorFactory is the factory method for http monitor
 func MonitorFactory(config *config.Config) (common.IMonitor, error) {
 	mon := &HttpMonitor{}
 
-	mon.Timeout = config.Timeout
 	mon.Logger = config.Logger
 
-	for _, srvc := range config.MonitoredServices.Http {
-		mon.Http = append(
-			mon.Http,
-			HttpEndpoints{
-				Url:          srvc.Endpoint,
-				Result:       map[Url]string{Url(srvc.Endpoint): ""},
-				SearchString: map[Url]string{Url(srvc.Endpoint): srvc.ExpectedResponse},
-				Healthy:      map[Url]bool{Url(srvc.Endpoint): false},
-			})
+	if err := mon.Reload(config); err != nil {
+		return nil, err
 	}
+
 	return mon, nil
 }
 
+// Reload replaces the monitored endpoints with the ones of the config. An endpoint that keeps its name also
+// keeps the start of its current outage, and its last health check if the check did not change.
+func (m *HttpMonitor) Reload(config *config.Config) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	endpoints := make([]HttpEndpoints, 0, len(config.MonitoredServices.Http))
+	index := map[string]int{}
+
+	for _, srvc := range config.MonitoredServices.Http {
+		endpoint := HttpEndpoints{
+			Name:           srvc.Name,
+			Url:            srvc.Endpoint,
+			Severity:       srvc.Severity,
+			Result:         map[Url]string{Url(srvc.Endpoint): ""},
+			SearchString:   map[Url]string{Url(srvc.Endpoint): srvc.ExpectedResponse},
+			Healthy:        map[Url]bool{Url(srvc.Endpoint): false},
+			ExpectedStatus: srvc.ExpectedStatus,
+			DependsOn:      srvc.DependsOn,
+			Interval:       time.Duration(*srvc.Interval),
+			Timeout:        time.Duration(*srvc.Timeout),
+			Retries:        *srvc.Retries,
+			RetryDelay:     time.Duration(*srvc.RetryDelay),
+		}
+
+		if i, ok := m.index[srvc.Name]; ok {
+			previous := m.Http[i]
+			endpoint.DownSince = previous.DownSince
+
+			if previous.sameCheck(endpoint) {
+				endpoint.Result = previous.Result
+				endpoint.Healthy = previous.Healthy
+				endpoint.StatusCode = previous.StatusCode
+				endpoint.Error = previous.Error
+				endpoint.LastCheck = previous.LastCheck
+				endpoint.Failures = previous.Failures
+				endpoint.Downtime = previous.Downtime
+				endpoint.Pending = previous.Pending
+			}
+		}
+
+		endpoints = append(endpoints, endpoint)
+		index[srvc.Name] = len(endpoints) - 1
+	}
+
+	m.Http = endpoints
+	m.index = index
+
+	if m.finished != nil {
+		m.startSchedulers()
+	}
+
+	return nil
+}
+
+// sameCheck returns true if the endpoint is checked the same way as the other one
+func (e HttpEndpoints) sameCheck(other HttpEndpoints) bool {
+	return e.Url == other.Url && e.SearchString[Url(e.Url)] == other.SearchString[Url(other.Url)] &&
+		e.ExpectedStatus == other.ExpectedStatus
+}
+
 // SetNotifier sets the sender notifier interface
 func (m *HttpMonitor) SetNotifier(notifier notifyCommon.INotifier) {
 	m.Sender = notifier
 }
 
-// Run runs the health check and sends notifications
+// Run runs the health check of every endpoint once, waiting for the retries of the failed ones, and sends notifications
 func (m *HttpMonitor) Run() common.IMonitor {
+	m.Check()
+	m.sendNotifications()
+
+	return m
+}
+
+// Check runs the health check of every endpoint, waiting for the retries of the failed ones, without sending notifications
+func (m *HttpMonitor) Check() common.IMonitor {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	m.query(true)
+	m.checkHealth()
+
+	for m.retrying() {
+		time.Sleep(time.Until(m.next()))
+		m.query(false)
+		m.checkHealth()
+	}
+
+	for i := range m.Http {
+		m.Http[i].Checked = true
+	}
+	m.writeLogs()
+
+	return m
+}
+
+// Start runs the health check of every endpoint on its own interval in the background, so a slow endpoint
+// does not delay the others. The logs and notifications of the finished checks are handled one batch at a time.
+func (m *HttpMonitor) Start() {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	if m.finished != nil {
+		return
+	}
+
+	m.finished = make(chan struct{}, 1)
+	m.schedulers = map[string]*scheduler{}
+
+	go m.process()
+	m.startSchedulers()
+}
+
+// startSchedulers starts the schedulers of the new endpoints, stops the ones of the removed endpoints
+// and wakes the others to pick up their reloaded interval
+func (m *HttpMonitor) startSchedulers() {
+	for name, s := range m.schedulers {
+		if _, ok := m.index[name]; !ok {
+			close(s.stop)
+			delete(m.schedulers, name)
+			continue
+		}
+
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+	}
+
+	for _, mon := range m.Http {
+		if _, ok := m.schedulers[mon.Name]; ok {
+			continue
+		}
+
+		s := &scheduler{wake: make(chan struct{}, 1), stop: make(chan struct{})}
+		m.schedulers[mon.Name] = s
+
+		go m.schedule(mon.Name, s)
+	}
+}
+
+// schedule runs the health checks of the endpoint whenever they are due, until the scheduler is stopped
+func (m *HttpMonitor) schedule(name string, s *scheduler) {
+	for {
+		m.mux.Lock()
+		i, ok := m.index[name]
+		var due time.Time
+		if ok {
+			due = m.Http[i].due()
+		}
+		m.mux.Unlock()
+
+		if !ok {
+			return
+		}
+
+		timer := time.NewTimer(time.Until(due))
+
+		select {
+		case <-s.stop:
+			timer.Stop()
+			return
+		case <-s.wake:
+			timer.Stop()
+			continue
+		case <-timer.C:
+		}
+
+		m.checkEndpoint(name)
+	}
+}
+
+// checkEndpoint runs the health check of the endpoint and signals that it finished, unless the failed check is retried.
+// The endpoints are not locked while the request is in flight.
+func (m *HttpMonitor) checkEndpoint(name string) {
+	m.mux.Lock()
+	i, ok := m.index[name]
+	if !ok {
+		m.mux.Unlock()
+		return
+	}
+	m.Http[i].LastCheck = time.Now()
+	target := m.Http[i]
+	m.mux.Unlock()
+
+	resp := m.get(target)
+
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	// the check of the endpoint was changed by a reload while the request was in flight
+	i, ok = m.index[name]
+	if !ok || !m.Http[i].sameCheck(target) {
+		return
+	}
+
+	e := &m.Http[i]
+	e.apply(resp)
+
+	if m.evaluate(e) {
+		e.Pending = true
+
+		select {
+		case m.finished <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// process writes the logs and sends the notifications of the finished checks, the checks that finish
+// while the notifications are sent are handled together in the next batch
+func (m *HttpMonitor) process() {
+	for range m.finished {
+		m.mux.Lock()
+
+		for i := range m.Http {
+			m.Http[i].Checked = m.Http[i].Pending
+			m.Http[i].Pending = false
+		}
+
+		m.writeLogs()
+		recovery, event := m.recoveryEvent(), m.event()
+
+		for i := range m.Http {
+			if m.Http[i].Checked {
+				m.Http[i].Downtime = 0
+			}
+		}
+
+		m.mux.Unlock()
+
+		m.send(recovery, event)
+	}
+}
+
+// next returns the time the next health check is due
+func (m *HttpMonitor) next() time.Time {
+	var next time.Time
+
+	for _, mon := range m.Http {
+		due := mon.due()
+		if next.IsZero() || due.Before(next) {
+			next = due
+		}
+	}
+
+	return next
+}
+
+// Status returns the state of every endpoint after the last health check
+func (m *HttpMonitor) Status() []notifyCommon.Endpoint {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	endpoints := make([]notifyCommon.Endpoint, 0, len(m.Http))
+	for _, mon := range m.Http {
+		endpoints = append(endpoints, mon.endpoint())
+	}
+
+	return endpoints
+}
+
+// query fetches the endpoints that are due, or all of them, in parallel and returns the number of the fetched endpoints
+func (m *HttpMonitor) query(all bool) int {
 	wg := sync.WaitGroup{}
-	mux := sync.Mutex{}
+	now := time.Now()
+	queried := 0
 
 	// fetch endpoints
-	for _, httpEndpoint := range m.Http {
+	for i := range m.Http {
+		m.Http[i].Checked = all || !now.Before(m.Http[i].due())
+		if !m.Http[i].Checked {
+			continue
+		}
+
+		m.Http[i].LastCheck = now
+		queried++
 		wg.Add(1)
 
-		// query endpoints in parallel
-		go func(httpEndpoint HttpEndpoints) {
+		// query endpoints in parallel, each of them only updates its own endpoint
+		go func(httpEndpoint *HttpEndpoints) {
 			defer wg.Done()
 
-			client := http.Client{Timeout: time.Duration(m.Timeout) * time.Second}
+			httpEndpoint.apply(m.get(*httpEndpoint))
+		}(&m.Http[i])
+	}
 
-			resp, err := client.Get(httpEndpoint.Url)
-			if err != nil {
-				m.Logger.Debug("could not send request to", "url", httpEndpoint.Url)
-				mux.Lock()
-				httpEndpoint.Error = fmt.Errorf("could not send GET request err=%w", err)
-				mux.Unlock()
-			}
-			defer func(Body io.ReadCloser) {
-				_ = Body.Close()
-			}(resp.Body)
-
-			body, err := ioutil.ReadAll(resp.Body)
-			if err != nil {
-				m.Logger.Debug("response body", "body", string(body))
-				mux.Lock()
-				httpEndpoint.Error = fmt.Errorf("could not read the responce body err=%w", err)
-				mux.Unlock()
-			}
+	wg.Wait()
 
-			mux.Lock()
-			httpEndpoint.Result[Url(httpEndpoint.Url)] = string(body)
-			mux.Unlock()
+	return queried
+}
+
+// get sends the health check request of the endpoint
+func (m *HttpMonitor) get(httpEndpoint HttpEndpoints) response {
+	client := http.Client{Timeout: httpEndpoint.Timeout}
 
-			m.Logger.Info("successfully queried defined url", "url", httpEndpoint.Url)
-		}(httpEndpoint)
+	resp, err := client.Get(httpEndpoint.Url)
+	if err != nil {
+		m.Logger.Debug("could not send request to", "url", httpEndpoint.Url)
+		return response{err: fmt.Errorf("could not send GET request err=%w", err)}
 	}
+	defer func(Body io.ReadCloser) {
+		_ = Body.Close()
+	}(resp.Body)
 
-	wg.Wait()
+	result := response{status: resp.StatusCode}
 
-	m.checkHealth().writeLogs().sendNotifications()
-	return m
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		m.Logger.Debug("response body", "body", string(body))
+		result.err = fmt.Errorf("could not read the responce body err=%w", err)
+	}
+	result.body = string(body)
+
+	m.Logger.Info("successfully queried defined url", "url", httpEndpoint.Url)
+
+	return result
+}
+
+// apply stores the response of the health check request on the endpoint
+func (e *HttpEndpoints) apply(resp response) {
+	e.Error = resp.err
+	e.Result[Url(e.Url)] = resp.body
+	e.StatusCode = resp.status
+}
+
+// due returns the time the next health check of the endpoint is due, which is sooner while a failed check is retried
+func (e HttpEndpoints) due() time.Time {
+	if e.Failures > 0 {
+		return e.LastCheck.Add(e.RetryDelay)
+	}
+
+	return e.LastCheck.Add(e.Interval)
+}
+
+// retrying returns true if a failed check of any endpoint is being retried
+func (m *HttpMonitor) retrying() bool {
+	for _, mon := range m.Http {
+		if mon.Failures > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// healthy returns true if the last response contains the expected response and has the expected status
+func (e HttpEndpoints) healthy() bool {
+	return strings.Contains(e.Result[Url(e.Url)], e.SearchString[Url(e.Url)]) &&
+		(e.ExpectedStatus == 0 || e.StatusCode == e.ExpectedStatus)
 }
 
 // checkHealth checks the data received and creates a map with bool health values
 func (m *HttpMonitor) checkHealth() *HttpMonitor {
-	// check if the strings are found in results
-	for _, e := range m.Http {
-		e.Healthy[Url(e.Url)] = strings.Contains(e.Result[Url(e.Url)], e.SearchString[Url(e.Url)])
-		switch e.Healthy[Url(e.Url)] {
-		case true:
-			m.Logger.Info("service health", "url", e.Url, "status", "HEALTHY")
-		case false:
-			m.Logger.Info("service health", "url", e.Url, "status", "NOT-HEALTHY")
+	for i := range m.Http {
+		e := &m.Http[i]
+		e.Downtime = 0
+		if !e.Checked {
+			continue
 		}
+
+		e.Checked = m.evaluate(e)
 	}
 
 	return m
 }
 
+// evaluate updates the health of the endpoint from its last check. A failed check of an endpoint that is not down yet
+// is retried, keeping the last state of the endpoint until then, in which case false is returned.
+func (m *HttpMonitor) evaluate(e *HttpEndpoints) bool {
+	if !e.healthy() && e.DownSince.IsZero() && e.Failures < e.Retries {
+		e.Failures++
+		m.Logger.Debug("health check failed, retrying", "url", e.Url, "retry", e.Failures, "delay", e.RetryDelay)
+		return false
+	}
+	e.Failures = 0
+
+	e.Healthy[Url(e.Url)] = e.healthy()
+
+	switch e.Healthy[Url(e.Url)] {
+	case true:
+		m.Logger.Info("service health", "url", e.Url, "status", "HEALTHY")
+
+		if !e.DownSince.IsZero() {
+			e.Downtime = time.Since(e.DownSince)
+			e.DownSince = time.Time{}
+		}
+	case false:
+		m.Logger.Info("service health", "url", e.Url, "status", "NOT-HEALTHY")
+
+		if e.DownSince.IsZero() {
+			e.DownSince = time.Now()
+		}
+	}
+
+	return true
+}
+
 // writeLogs writes the logs in the console
-func (m HttpMonitor) writeLogs() *HttpMonitor {
-	for _, mon := range m.Http {
-		if !mon.Healthy[Url(mon.Url)] {
+func (m *HttpMonitor) writeLogs() *HttpMonitor {
+	for i, mon := range m.Http {
+		if !mon.Checked {
+			continue
+		}
+
+		if parent := m.unreachableThrough(i); parent >= 0 {
+			m.Logger.Warn("Service unreachable due to parent", "url", mon.Url, "parent", m.Http[parent].Name)
+		} else if !mon.Healthy[Url(mon.Url)] {
 			m.Logger.Warn("Service health entered ALARM state", "url", mon.Url)
 		} else {
 			m.Logger.Info("Service HEALTHY", "url", mon.Url)
 		}
 	}
 
-	return &m
+	return m
 }
 
 // RunMock doesn't send any notifications
-func (m HttpMonitor) RunMock() {
+func (m *HttpMonitor) RunMock() {
 	for _, mon := range m.Http {
 
 		if !mon.Healthy[Url(mon.Url)] {
@@ -153,14 +528,32 @@ func (m HttpMonitor) RunMock() {
 	}
 }
 
+// sendNotifications sends the notifications of the endpoints checked in the last run
 func (m *HttpMonitor) sendNotifications() {
-	for _, mon := range m.Http {
+	m.mux.Lock()
+	recovery, event := m.recoveryEvent(), m.event()
+	m.mux.Unlock()
 
-		if !mon.Healthy[Url(mon.Url)] {
+	m.send(recovery, event)
+}
+
+// send sends the recovery event and the alert event, if any of their endpoints is failing
+func (m *HttpMonitor) send(recovery, event notifyCommon.Event) {
+	if len(recovery.Endpoints) > 0 {
+		m.Logger.Info("Sending recovery notifications...", "recovered", len(recovery.Endpoints))
+
+		if err := m.Sender.Send(recovery); err != nil {
+			m.Logger.Error("Could not send recovery notifications", "error", err.Error())
+		}
+	}
+
+	for _, mon := range event.Endpoints {
+
+		if mon.Status != notifyCommon.StatusUp {
 
 			m.Logger.Info("Sending notifications...", "url", mon.Url)
 
-			if err := m.Sender.Send(m); err != nil {
+			if err := m.Sender.Send(event); err != nil {
 				m.Logger.Error("Could not send notifications", "url", mon.Url, "error", err.Error())
 			}
 			// we break from send notification on first failed as one notification is enough
@@ -168,3 +561,113 @@ func (m *HttpMonitor) sendNotifications() {
 		}
 	}
 }
+
+// event creates a notification event from the endpoints checked in the last run.
+// The failing endpoints whose parent is down are not added on their own, but listed on the parent,
+// which is added to the event if either of them was checked.
+func (m *HttpMonitor) event() notifyCommon.Event {
+	included := map[int]bool{}
+	parents := map[int]int{}
+
+	for i, mon := range m.Http {
+		if parent := m.unreachableThrough(i); parent >= 0 {
+			if mon.Checked || m.Http[parent].Checked {
+				included[parent] = true
+				parents[i] = parent
+			}
+			continue
+		}
+
+		if mon.Checked {
+			included[i] = true
+		}
+	}
+
+	endpoints := make([]notifyCommon.Endpoint, 0, len(included))
+	position := map[int]int{}
+
+	for i, mon := range m.Http {
+		if included[i] {
+			position[i] = len(endpoints)
+			endpoints = append(endpoints, mon.endpoint())
+		}
+	}
+
+	for i, mon := range m.Http {
+		if parent, ok := parents[i]; ok {
+			endpoints[position[parent]].Unreachable = append(endpoints[position[parent]].Unreachable, mon.endpoint())
+		}
+	}
+
+	return notifyCommon.NewEvent(notifyCommon.KindAlert, endpoints)
+}
+
+// endpoint returns the state of the endpoint after the last health check
+func (e HttpEndpoints) endpoint() notifyCommon.Endpoint {
+	endpoint := notifyCommon.Endpoint{
+		Name:     e.Name,
+		Url:      e.Url,
+		Status:   notifyCommon.StatusUp,
+		Severity: notifyCommon.Severity(e.Severity),
+	}
+
+	if !e.Healthy[Url(e.Url)] {
+		endpoint.Status = notifyCommon.StatusDown
+		endpoint.Since = e.DownSince
+
+		endpoint.Error = fmt.Sprintf("expected response %q not found", e.SearchString[Url(e.Url)])
+		switch {
+		case e.Error != nil:
+			endpoint.Error = e.Error.Error()
+		case e.ExpectedStatus != 0 && e.StatusCode != e.ExpectedStatus:
+			endpoint.Error = fmt.Sprintf("expected status %d, got %d", e.ExpectedStatus, e.StatusCode)
+		}
+	}
+
+	return endpoint
+}
+
+// unreachableThrough returns the index of the failing monitor the failing monitor at index i can not be reached through,
+// which is the topmost failing one of its parents, or -1 if the monitor is healthy or none of its parents is failing
+func (m *HttpMonitor) unreachableThrough(i int) int {
+	if m.Http[i].Healthy[Url(m.Http[i].Url)] {
+		return -1
+	}
+
+	for _, name := range m.Http[i].DependsOn {
+		parent, ok := m.index[name]
+		if !ok || m.Http[parent].Healthy[Url(m.Http[parent].Url)] {
+			continue
+		}
+
+		if top := m.unreachableThrough(parent); top >= 0 {
+			return top
+		}
+
+		return parent
+	}
+
+	return -1
+}
+
+// recoveryEvent creates a notification event with the endpoints that recovered in the last run
+func (m *HttpMonitor) recoveryEvent() notifyCommon.Event {
+	var endpoints []notifyCommon.Endpoint
+
+	for _, mon := range m.Http {
+		if mon.Downtime == 0 {
+			continue
+		}
+
+		endpoints = append(endpoints, notifyCommon.Endpoint{
+			Name:     mon.Name,
+			Url:      mon.Url,
+			Status:   notifyCommon.StatusUp,
+			Severity: notifyCommon.Severity(mon.Severity),
+			Since:    time.Now(),
+			Downtime: mon.Downtime,
+		})
+	}
+
+	return notifyCommon.NewEvent(notifyCommon.KindRecovery, endpoints)
+}
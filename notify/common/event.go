@@ -0,0 +1,292 @@
+package common
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+)
+
+// Status is the health status of a single monitored endpoint
+type Status string
+
+const (
+	StatusUp   Status = "UP"
+	StatusDown Status = "DOWN"
+)
+
+// Severity is the importance of a monitored endpoint going down
+type Severity string
+
+const (
+	SeverityInfo     Severity = "info"
+	SeverityWarning  Severity = "warning"
+	SeverityCritical Severity = "critical"
+)
+
+// level returns a comparable weight of the severity, unknown severities are treated as critical
+func (s Severity) level() int {
+	switch s {
+	case SeverityInfo:
+		return 0
+	case SeverityWarning:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// AtLeast returns true if the severity is the same or higher than the provided one
+func (s Severity) AtLeast(other Severity) bool {
+	return s.level() >= other.level()
+}
+
+// Kind is the reason the event is sent
+type Kind string
+
+const (
+	// KindAlert reports the endpoints that are down
+	KindAlert Kind = "alert"
+	// KindReminder reports the endpoints that are still down
+	KindReminder Kind = "reminder"
+	// KindRecovery reports the endpoints that are healthy again
+	KindRecovery Kind = "recovery"
+	// KindDigest combines all the events collected over the digest window
+	KindDigest Kind = "digest"
+	// KindSummary reports the number of alerts suppressed by the rate limit
+	KindSummary Kind = "summary"
+)
+
+// Endpoint holds the check result of a single monitored endpoint
+type Endpoint struct {
+	Name     string   `json:"name"`
+	Url      string   `json:"url"`
+	Status   Status   `json:"status"`
+	Severity Severity `json:"severity"`
+	Error    string   `json:"error,omitempty"`
+	// Since is the time the endpoint went down, or the time it recovered
+	Since time.Time `json:"since,omitempty"`
+	// Downtime is the duration of the outage of a recovered endpoint
+	Downtime time.Duration `json:"downtime,omitempty"`
+	// Alerts is the number of alerts collected for the endpoint in a digest
+	Alerts int `json:"alerts,omitempty"`
+	// Incident is the ID of the open incident of a failing endpoint
+	Incident string `json:"incident,omitempty"`
+	// AckUrl is the signed link used to acknowledge the open incident
+	AckUrl string `json:"ack_url,omitempty"`
+	// Unreachable are the failing endpoints that depend on this one, which are not notified separately
+	Unreachable []Endpoint `json:"unreachable,omitempty"`
+}
+
+// Details returns the status of the endpoint with the outage duration and alert count, if they are known
+func (ep Endpoint) Details() string {
+	details := []string{string(ep.Status)}
+
+	switch {
+	case ep.Status != StatusUp && !ep.Since.IsZero():
+		details[0] += " for " + FormatDuration(time.Since(ep.Since))
+	case ep.Status == StatusUp && ep.Downtime > 0:
+		details = append(details, "was down for "+FormatDuration(ep.Downtime))
+	}
+
+	if ep.Alerts > 0 {
+		details = append(details, fmt.Sprintf("%d alerts", ep.Alerts))
+	}
+
+	if len(ep.Unreachable) > 0 {
+		details = append(details, fmt.Sprintf("%d dependent services unreachable", len(ep.Unreachable)))
+	}
+
+	return strings.Join(details, ", ")
+}
+
+// UnreachableNames returns the names of the unreachable dependent endpoints as a comma separated list
+func (ep Endpoint) UnreachableNames() string {
+	names := make([]string, 0, len(ep.Unreachable))
+	for _, child := range ep.Unreachable {
+		names = append(names, child.Name)
+	}
+
+	return strings.Join(names, ", ")
+}
+
+// Event is the data handed over to the notifiers on every notification
+type Event struct {
+	// ID uniquely identifies the event and stays the same if the event is sent more than once
+	ID        string     `json:"id"`
+	Kind      Kind       `json:"kind"`
+	Time      time.Time  `json:"time"`
+	Endpoints []Endpoint `json:"endpoints"`
+	// Suppressed is the number of alerts held back by the rate limit since the last notification
+	Suppressed uint64 `json:"suppressed,omitempty"`
+}
+
+// NewEvent creates a new event of the provided kind with a unique ID
+func NewEvent(kind Kind, endpoints []Endpoint) Event {
+	return Event{
+		ID:        NewID(),
+		Kind:      kind,
+		Time:      time.Now(),
+		Endpoints: endpoints,
+	}
+}
+
+// Failing returns only the endpoints that are not healthy
+func (e Event) Failing() []Endpoint {
+	failing := make([]Endpoint, 0, len(e.Endpoints))
+	for _, ep := range e.Endpoints {
+		if ep.Status != StatusUp {
+			failing = append(failing, ep)
+		}
+	}
+
+	return failing
+}
+
+// Affected returns the endpoints the event is about, which are the failing ones
+// for alerts, and all the endpoints for the other kinds of events
+func (e Event) Affected() []Endpoint {
+	if e.Kind == KindAlert || e.Kind == "" {
+		return e.Failing()
+	}
+
+	return e.Endpoints
+}
+
+// Severity returns the highest severity of all failing endpoints
+func (e Event) Severity() Severity {
+	severity := SeverityInfo
+	for _, ep := range e.Failing() {
+		if ep.Severity.level() > severity.level() {
+			severity = ep.Severity
+		}
+	}
+
+	return severity
+}
+
+// Url returns the url of the first failing endpoint, falling back to the first affected one
+func (e Event) Url() string {
+	if failing := e.Failing(); len(failing) > 0 {
+		return failing[0].Url
+	}
+
+	if affected := e.Affected(); len(affected) > 0 {
+		return affected[0].Url
+	}
+
+	return ""
+}
+
+// Title returns a short one line description of the event
+func (e Event) Title() string {
+	switch e.Kind {
+	case KindSummary:
+		return fmt.Sprintf("[GONOTIFY] %d alerts suppressed by the rate limit", e.Suppressed)
+	case KindReminder:
+		return fmt.Sprintf("[GONOTIFY] REMINDER: %d services still DOWN", len(e.Failing()))
+	case KindRecovery:
+		return fmt.Sprintf("[GONOTIFY] %d services RECOVERED", len(e.Endpoints))
+	case KindDigest:
+		return fmt.Sprintf("[GONOTIFY] Digest: %d services affected, %d still down", len(e.Endpoints), len(e.Failing()))
+	default:
+		return fmt.Sprintf("[GONOTIFY] %d of %d services entered an ALARM state", len(e.Failing()), len(e.Endpoints))
+	}
+}
+
+// SuppressedText returns the note about the alerts held back by the rate limit, if there were any
+func (e Event) SuppressedText() string {
+	if e.Suppressed == 0 || e.Kind == KindSummary {
+		return ""
+	}
+
+	return fmt.Sprintf("%d more alerts were suppressed by the rate limit", e.Suppressed)
+}
+
+// Text returns the plain text body of the event
+func (e Event) Text() string {
+	sb := strings.Builder{}
+	sb.WriteString(e.Title())
+
+	for _, ep := range e.Affected() {
+		sb.WriteString(fmt.Sprintf("\n- %s (%s)", ep.Url, ep.Details()))
+		if ep.Error != "" {
+			sb.WriteString(": " + ep.Error)
+		}
+		if len(ep.Unreachable) > 0 {
+			sb.WriteString("\n  unreachable due to this service: " + ep.UnreachableNames())
+		}
+		if ep.AckUrl != "" {
+			sb.WriteString("\n  acknowledge: " + ep.AckUrl)
+		}
+	}
+
+	if suppressed := e.SuppressedText(); suppressed != "" {
+		sb.WriteString("\n" + suppressed)
+	}
+
+	return sb.String()
+}
+
+// HTML returns the event body formatted as simple HTML
+func (e Event) HTML() string {
+	sb := strings.Builder{}
+	sb.WriteString("<p><strong>" + html.EscapeString(e.Title()) + "</strong></p><ul>")
+
+	for _, ep := range e.Affected() {
+		sb.WriteString(fmt.Sprintf(
+			`<li><a href="%s">%s</a> <strong>%s</strong>`,
+			html.EscapeString(ep.Url), html.EscapeString(ep.Url), html.EscapeString(ep.Details()),
+		))
+		if ep.Error != "" {
+			sb.WriteString(": <code>" + html.EscapeString(ep.Error) + "</code>")
+		}
+		if len(ep.Unreachable) > 0 {
+			sb.WriteString("<br>unreachable due to this service: " + html.EscapeString(ep.UnreachableNames()))
+		}
+		if ep.AckUrl != "" {
+			sb.WriteString(` (<a href="` + html.EscapeString(ep.AckUrl) + `">acknowledge</a>)`)
+		}
+		sb.WriteString("</li>")
+	}
+
+	sb.WriteString("</ul>")
+
+	if suppressed := e.SuppressedText(); suppressed != "" {
+		sb.WriteString("<p><em>" + suppressed + "</em></p>")
+	}
+
+	return sb.String()
+}
+
+// FormatDuration formats the duration rounded to seconds, or to minutes for durations over an hour
+func FormatDuration(d time.Duration) string {
+	if d >= time.Hour {
+		d = d.Round(time.Minute)
+	} else {
+		d = d.Round(time.Second)
+	}
+
+	formatted := d.String()
+	if strings.HasSuffix(formatted, "m0s") {
+		formatted = strings.TrimSuffix(formatted, "0s")
+	}
+	if strings.HasSuffix(formatted, "h0m") {
+		formatted = strings.TrimSuffix(formatted, "0m")
+	}
+
+	return formatted
+}
+
+// NewID returns a random hex encoded identifier
+func NewID() string {
+	buff := make([]byte, 16)
+	if _, err := rand.Read(buff); err != nil {
+		// fall back to the current time if there is no randomness available
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+
+	return hex.EncodeToString(buff)
+}
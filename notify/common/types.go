@@ -6,7 +6,7 @@ import (
 
 // INotifier is an interface that all notification services need to implement
 type INotifier interface {
-	Send(sendData interface{}) error
+	Send(event Event) error
 	SendMockup() error
 	WithConfig(config *config.Config) (INotifier, error)
 }
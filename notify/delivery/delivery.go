@@ -0,0 +1,286 @@
+package delivery
+
+import (
+	"fmt"
+	"github.com/ZeljkoBenovic/go-notify/config"
+	"github.com/ZeljkoBenovic/go-notify/notify/common"
+	"github.com/hashicorp/go-hclog"
+	"math/rand"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// queueSize is the number of events waiting for delivery on a channel,
+// the events that do not fit are spooled
+const queueSize = 100
+
+// Delivery sits in front of a notifier, queueing the events and delivering them one at a time from its own
+// goroutine, so a slow or failing notifier does not hold up the health checks, and the notifier is never
+// used concurrently. Failed sends are retried with exponential backoff. Events that could not be delivered
+// after all retries are stored in a spool, and replayed on start, after the next successful send
+// and on every replay interval.
+type Delivery struct {
+	notifier common.INotifier
+	channel  string
+
+	retries        uint64
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+
+	spool  *spool
+	logger hclog.Logger
+
+	queue   chan common.Event
+	replays chan struct{}
+	stopped chan struct{}
+	done    chan struct{}
+
+	// mux guards the closing of the queue, and the replay interval which is changed on reload
+	mux            sync.Mutex
+	closed         bool
+	replayInterval time.Duration
+}
+
+// NewDelivery wraps the notifier of the provided channel with the delivery layer and starts its delivery and spool replay
+func NewDelivery(notifier common.INotifier, channel string, config *config.Config) (*Delivery, error) {
+	d := &Delivery{
+		notifier:       notifier,
+		channel:        channel,
+		retries:        config.Delivery.Retries,
+		initialBackoff: time.Duration(config.Delivery.InitialBackoff),
+		maxBackoff:     time.Duration(config.Delivery.MaxBackoff),
+		replayInterval: time.Duration(config.Interval),
+		logger:         config.Logger.Named("delivery").With("channel", channel),
+		queue:          make(chan common.Event, queueSize),
+		replays:        make(chan struct{}, 1),
+		stopped:        make(chan struct{}),
+		done:           make(chan struct{}),
+	}
+
+	if d.maxBackoff < d.initialBackoff {
+		d.maxBackoff = d.initialBackoff
+	}
+
+	if config.Delivery.SpoolDir != "" {
+		spool, err := newSpool(filepath.Join(config.Delivery.SpoolDir, channel))
+		if err != nil {
+			return nil, err
+		}
+		d.spool = spool
+
+		go d.replayLoop()
+	}
+
+	go d.run()
+
+	return d, nil
+}
+
+// Send queues the event for delivery, it is spooled if the queue is full
+func (d *Delivery) Send(event common.Event) error {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	if d.closed {
+		return fmt.Errorf("channel %s is closed", d.channel)
+	}
+
+	select {
+	case d.queue <- event:
+		return nil
+	default:
+	}
+
+	if d.spool == nil {
+		return fmt.Errorf("delivery queue is full, event %s dropped", event.ID)
+	}
+
+	if err := d.spool.save(event); err != nil {
+		return fmt.Errorf("delivery queue is full, could not spool event: %w", err)
+	}
+
+	d.logger.Warn("delivery queue is full, event spooled for later delivery", "event", event.ID)
+	return nil
+}
+
+func (d *Delivery) SendMockup() error {
+	return d.notifier.SendMockup()
+}
+
+func (d *Delivery) WithConfig(config *config.Config) (common.INotifier, error) {
+	notifier, err := d.notifier.WithConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	d.notifier = notifier
+
+	return d, nil
+}
+
+// Reload applies the replay interval of the config, the current wait for the next replay is not changed
+func (d *Delivery) Reload(config *config.Config) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	d.replayInterval = time.Duration(config.Interval)
+}
+
+// run delivers the queued events and replays the spool when asked to, until the queue is closed
+func (d *Delivery) run() {
+	defer close(d.stopped)
+
+	for {
+		select {
+		case event, ok := <-d.queue:
+			if !ok {
+				return
+			}
+			d.deliver(event)
+		case <-d.replays:
+			d.replay()
+		}
+	}
+}
+
+// deliver sends the event, retrying on failure, and spools it if all retries fail
+func (d *Delivery) deliver(event common.Event) {
+	if err := d.sendWithRetry(event); err != nil {
+		if d.spool == nil {
+			d.logger.Error("could not deliver event", "event", event.ID, "error", err.Error())
+			return
+		}
+
+		if spoolErr := d.spool.save(event); spoolErr != nil {
+			d.logger.Error("could not deliver event, could not spool it", "event", event.ID, "error", err.Error(), "spool_error", spoolErr.Error())
+			return
+		}
+
+		d.logger.Warn("event spooled for later delivery", "event", event.ID, "error", err.Error())
+		return
+	}
+
+	// the channel is healthy, so deliver what was left behind
+	d.replay()
+}
+
+// replay tries to deliver all spooled events, stopping on the first failure. The spool is locked during the
+// replay, as the previous delivery of the channel can still be replaying it after a reload.
+func (d *Delivery) replay() {
+	if d.spool == nil {
+		return
+	}
+
+	d.spool.replayMux.Lock()
+	defer d.spool.replayMux.Unlock()
+
+	files, err := d.spool.list()
+	if err != nil {
+		d.logger.Error("could not list spooled events", "error", err.Error())
+		return
+	}
+
+	for _, file := range files {
+		event, err := d.spool.load(file)
+		if err != nil {
+			d.logger.Error("could not load spooled event, skipping", "file", file, "error", err.Error())
+			continue
+		}
+
+		if err := d.notifier.Send(event); err != nil {
+			d.logger.Debug("channel still unhealthy, replay stopped", "error", err.Error())
+			return
+		}
+
+		if err := d.spool.remove(file); err != nil {
+			d.logger.Error("could not remove delivered event from spool", "file", file, "error", err.Error())
+			return
+		}
+
+		d.logger.Info("spooled event delivered", "event", event.ID)
+	}
+}
+
+// replayLoop asks for the spool replay on start and on every replay interval
+func (d *Delivery) replayLoop() {
+	for {
+		select {
+		case d.replays <- struct{}{}:
+		default:
+		}
+
+		d.mux.Lock()
+		interval := d.replayInterval
+		d.mux.Unlock()
+
+		if interval <= 0 {
+			return
+		}
+
+		timer := time.NewTimer(interval)
+
+		select {
+		case <-timer.C:
+		case <-d.done:
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// Close stops the spool replay and closes the queue, the events already queued are still delivered.
+// The events already spooled are replayed by the next delivery of the channel.
+func (d *Delivery) Close() {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	if d.closed {
+		return
+	}
+	d.closed = true
+
+	close(d.done)
+	close(d.queue)
+}
+
+// Wait waits until the events queued before Close are delivered
+func (d *Delivery) Wait() {
+	<-d.stopped
+}
+
+// sendWithRetry sends the event, retrying up to the configured number of retries
+func (d *Delivery) sendWithRetry(event common.Event) error {
+	var err error
+
+	for attempt := uint64(0); attempt <= d.retries; attempt++ {
+		if attempt > 0 {
+			wait := d.backoff(attempt)
+			d.logger.Warn("notification failed, retrying", "event", event.ID, "attempt", attempt, "wait", wait, "error", err.Error())
+			time.Sleep(wait)
+		}
+
+		if err = d.notifier.Send(event); err == nil {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("could not deliver event after %d retries: %w", d.retries, err)
+}
+
+// backoff returns the exponential backoff for the attempt, with a random jitter of up to half of it
+func (d *Delivery) backoff(attempt uint64) time.Duration {
+	wait := d.initialBackoff
+	for i := uint64(1); i < attempt && wait < d.maxBackoff; i++ {
+		wait *= 2
+	}
+
+	if wait > d.maxBackoff {
+		wait = d.maxBackoff
+	}
+
+	if wait <= 0 {
+		return 0
+	}
+
+	return wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1))
+}
@@ -0,0 +1,202 @@
+package delivery
+
+import (
+	"errors"
+	"github.com/ZeljkoBenovic/go-notify/config"
+	"github.com/ZeljkoBenovic/go-notify/notify/common"
+	"github.com/hashicorp/go-hclog"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recorder is a notifier keeping the events it was sent, after the delay. It fails the first fail sends.
+type recorder struct {
+	mux    sync.Mutex
+	events []common.Event
+	delay  time.Duration
+	fail   int
+}
+
+func (r *recorder) Send(event common.Event) error {
+	time.Sleep(r.delay)
+
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if r.fail > 0 {
+		r.fail--
+		return errors.New("channel down")
+	}
+
+	r.events = append(r.events, event)
+	return nil
+}
+
+func (r *recorder) SendMockup() error {
+	return nil
+}
+
+func (r *recorder) WithConfig(*config.Config) (common.INotifier, error) {
+	return r, nil
+}
+
+func (r *recorder) sent() []common.Event {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	return append([]common.Event(nil), r.events...)
+}
+
+func newTestConfig(spoolDir string) *config.Config {
+	return &config.Config{
+		Logger:   hclog.NewNullLogger(),
+		Delivery: config.Delivery{SpoolDir: spoolDir},
+	}
+}
+
+func TestDeliveryReplacedSpool(t *testing.T) {
+	conf := newTestConfig(t.TempDir())
+
+	s, err := newSpool(filepath.Join(conf.Delivery.SpoolDir, "test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		if err := s.save(common.NewEvent(common.KindAlert, nil)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the replaced delivery is still replaying the spool when the new one starts
+	notifier := &recorder{delay: 5 * time.Millisecond}
+	previous, err := NewDelivery(notifier, "test", conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	next, err := NewDelivery(notifier, "test", conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	previous.Close()
+	previous.Wait()
+	next.Close()
+	next.Wait()
+
+	sent := map[string]int{}
+	for _, event := range notifier.sent() {
+		sent[event.ID]++
+	}
+	if len(sent) != 10 {
+		t.Errorf("replayed %d events, want 10", len(sent))
+	}
+	for id, count := range sent {
+		if count != 1 {
+			t.Errorf("event %s sent %d times, want once", id, count)
+		}
+	}
+
+	if files, _ := s.list(); len(files) != 0 {
+		t.Errorf("%d events left in the spool, want none", len(files))
+	}
+}
+
+func TestDeliveryBackoff(t *testing.T) {
+	tests := []struct {
+		name    string
+		initial time.Duration
+		max     time.Duration
+		attempt uint64
+		want    time.Duration
+	}{
+		{name: "first retry", initial: time.Second, max: time.Minute, attempt: 1, want: time.Second},
+		{name: "doubled", initial: time.Second, max: time.Minute, attempt: 2, want: 2 * time.Second},
+		{name: "doubled twice", initial: time.Second, max: time.Minute, attempt: 3, want: 4 * time.Second},
+		{name: "capped", initial: time.Second, max: time.Minute, attempt: 10, want: time.Minute},
+		{name: "capped below the doubled backoff", initial: 20 * time.Second, max: 30 * time.Second, attempt: 2, want: 30 * time.Second},
+		{name: "no backoff", initial: 0, max: 0, attempt: 3, want: 0},
+	}
+
+	for _, tt := range tests {
+		d := &Delivery{initialBackoff: tt.initial, maxBackoff: tt.max}
+
+		// the jitter takes up to half of the backoff off
+		for i := 0; i < 20; i++ {
+			if got := d.backoff(tt.attempt); got < tt.want/2 || got > tt.want {
+				t.Errorf("%s: backoff(%d) = %v, want between %v and %v", tt.name, tt.attempt, got, tt.want/2, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestDeliverySpoolReplay(t *testing.T) {
+	tests := []struct {
+		name    string
+		retries uint64
+		fail    int
+		// spooled are the endpoints of the events spooled before the delivery starts
+		spooled   []string
+		send      []string
+		wantSent  []string
+		wantSpool int
+	}{
+		{name: "delivered", send: []string{"a", "b"}, wantSent: []string{"a", "b"}},
+		{name: "delivered after retries", retries: 2, fail: 2, send: []string{"a"}, wantSent: []string{"a"}},
+		{name: "spooled, replayed after the next delivery", fail: 1, send: []string{"a", "b"}, wantSent: []string{"a", "b"}},
+		{name: "spooled after all retries", retries: 1, fail: 10, send: []string{"a", "b"}, wantSpool: 2},
+		{name: "replayed on start", spooled: []string{"a", "b"}, send: []string{"c"}, wantSent: []string{"a", "b", "c"}},
+		{name: "replay stopped on failure", fail: 5, spooled: []string{"a", "b"}, wantSpool: 2},
+	}
+
+	for _, tt := range tests {
+		conf := newTestConfig(t.TempDir())
+		conf.Delivery.Retries = tt.retries
+
+		s, err := newSpool(filepath.Join(conf.Delivery.SpoolDir, "test"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range tt.spooled {
+			if err := s.save(common.NewEvent(common.KindAlert, []common.Endpoint{{Name: name}})); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		notifier := &recorder{fail: tt.fail}
+		d, err := NewDelivery(notifier, "test", conf)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, name := range tt.send {
+			if err := d.Send(common.NewEvent(common.KindAlert, []common.Endpoint{{Name: name}})); err != nil {
+				t.Errorf("%s: Send error = %v", tt.name, err)
+			}
+		}
+
+		d.Close()
+		d.Wait()
+
+		// the spool can be replayed before or after the queued events
+		var sent []string
+		for _, event := range notifier.sent() {
+			sent = append(sent, event.Endpoints[0].Name)
+		}
+		sort.Strings(sent)
+
+		if !reflect.DeepEqual(sent, tt.wantSent) {
+			t.Errorf("%s: sent %v, want %v", tt.name, sent, tt.wantSent)
+		}
+
+		if files, _ := s.list(); len(files) != tt.wantSpool {
+			t.Errorf("%s: %d events left in the spool, want %d", tt.name, len(files), tt.wantSpool)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package delivery
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ZeljkoBenovic/go-notify/notify/common"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const spoolFileExt = ".json"
+
+// spools are the spools of the directories in use. A channel replaced on reload gets the spool of the
+// previous delivery, which can still be replaying it, so the replays of both are not run at the same time.
+var (
+	spoolsMux sync.Mutex
+	spools    = map[string]*spool{}
+)
+
+// spool stores undelivered events of a single channel on disk
+type spool struct {
+	dir string
+	// replayMux is held during a replay, so a spooled event is not sent by two replays
+	replayMux sync.Mutex
+}
+
+// newSpool returns the spool of the directory, shared with the other deliveries of the directory
+func newSpool(dir string) (*spool, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("could not create spool directory: %w", err)
+	}
+
+	spoolsMux.Lock()
+	defer spoolsMux.Unlock()
+
+	if s, ok := spools[dir]; ok {
+		return s, nil
+	}
+
+	s := &spool{dir: dir}
+	spools[dir] = s
+
+	return s, nil
+}
+
+// save writes the event to the spool directory
+func (s *spool) save(event common.Event) error {
+	buff, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("could not marshal event: %w", err)
+	}
+
+	// the file name starts with the event time so the files are replayed in order
+	name := fmt.Sprintf("%d-%s%s", event.Time.UnixNano(), event.ID, spoolFileExt)
+
+	// write to a temporary file first so a crash can not leave a partial event behind
+	tmp := filepath.Join(s.dir, "."+name)
+	if err := ioutil.WriteFile(tmp, buff, 0600); err != nil {
+		return fmt.Errorf("could not write spool file: %w", err)
+	}
+
+	if err := os.Rename(tmp, filepath.Join(s.dir, name)); err != nil {
+		return fmt.Errorf("could not move spool file: %w", err)
+	}
+
+	return nil
+}
+
+// list returns the paths of all spooled events, oldest first
+func (s *spool) list() ([]string, error) {
+	entries, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("could not read spool directory: %w", err)
+	}
+
+	files := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") || filepath.Ext(entry.Name()) != spoolFileExt {
+			continue
+		}
+
+		files = append(files, filepath.Join(s.dir, entry.Name()))
+	}
+
+	sort.Strings(files)
+
+	return files, nil
+}
+
+// load reads a spooled event
+func (s *spool) load(path string) (common.Event, error) {
+	event := common.Event{}
+
+	buff, err := ioutil.ReadFile(path)
+	if err != nil {
+		return event, fmt.Errorf("could not read spool file: %w", err)
+	}
+
+	if err := json.Unmarshal(buff, &event); err != nil {
+		return event, fmt.Errorf("could not unmarshal spool file: %w", err)
+	}
+
+	return event, nil
+}
+
+// remove deletes a spooled event once it is delivered
+func (s *spool) remove(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("could not remove spool file: %w", err)
+	}
+
+	return nil
+}
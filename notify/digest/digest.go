@@ -0,0 +1,161 @@
+package digest
+
+import (
+	"github.com/ZeljkoBenovic/go-notify/config"
+	"github.com/ZeljkoBenovic/go-notify/notify/common"
+	"github.com/hashicorp/go-hclog"
+	"sync"
+	"time"
+)
+
+// Digest collects the alerts and recoveries of a channel over the digest window and sends them
+// as one combined message with the alert count, outage duration and current state of every endpoint.
+// Endpoints with the bypass severity, or higher, are sent right away.
+type Digest struct {
+	notifier common.INotifier
+
+	window time.Duration
+	bypass common.Severity
+
+	logger hclog.Logger
+
+	mux     sync.Mutex
+	entries map[string]*common.Endpoint
+	order   []string
+	flush   *time.Timer
+}
+
+// NewDigest wraps the notifier of the provided channel with the digest, using the digest settings of the channel
+func NewDigest(notifier common.INotifier, channel string, settings config.Digest, config *config.Config) *Digest {
+	return &Digest{
+		notifier: notifier,
+		window:   time.Duration(settings.Window),
+		bypass:   common.Severity(settings.BypassSeverity),
+		logger:   config.Logger.Named("digest").With("channel", channel),
+		entries:  map[string]*common.Endpoint{},
+	}
+}
+
+// Send adds the event to the digest, forwarding only the endpoints that bypass it
+func (d *Digest) Send(event common.Event) error {
+	if event.Kind != common.KindAlert && event.Kind != common.KindRecovery {
+		return d.notifier.Send(event)
+	}
+
+	bypassed := map[string]bool{}
+
+	d.mux.Lock()
+
+	for _, ep := range event.Affected() {
+		if d.bypass != "" && ep.Severity.AtLeast(d.bypass) {
+			bypassed[ep.Name] = true
+			continue
+		}
+
+		d.collect(ep, event.Kind)
+	}
+
+	if len(d.entries) > 0 && d.flush == nil {
+		d.flush = time.AfterFunc(d.window, d.sendDigest)
+	}
+
+	d.mux.Unlock()
+
+	if len(bypassed) == 0 {
+		d.logger.Debug("event added to digest", "event", event.ID)
+		return nil
+	}
+
+	// keep the healthy endpoints of an alert, so the message still shows how many services are checked
+	endpoints := make([]common.Endpoint, 0, len(event.Endpoints))
+	for _, ep := range event.Endpoints {
+		if bypassed[ep.Name] || (event.Kind == common.KindAlert && ep.Status == common.StatusUp) {
+			endpoints = append(endpoints, ep)
+		}
+	}
+	event.Endpoints = endpoints
+
+	d.logger.Debug("endpoints bypassed the digest", "event", event.ID, "bypassed", len(bypassed))
+
+	return d.notifier.Send(event)
+}
+
+func (d *Digest) SendMockup() error {
+	return d.notifier.SendMockup()
+}
+
+func (d *Digest) WithConfig(config *config.Config) (common.INotifier, error) {
+	notifier, err := d.notifier.WithConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	d.notifier = notifier
+
+	return d, nil
+}
+
+// Flush sends the collected endpoints right away, used when the channel is replaced
+func (d *Digest) Flush() {
+	d.mux.Lock()
+	if d.flush != nil {
+		d.flush.Stop()
+	}
+	d.mux.Unlock()
+
+	d.sendDigest()
+}
+
+// collect updates the digest entry of the endpoint with its latest state
+func (d *Digest) collect(ep common.Endpoint, kind common.Kind) {
+	entry, ok := d.entries[ep.Name]
+	if !ok {
+		entry = &common.Endpoint{
+			Name:     ep.Name,
+			Url:      ep.Url,
+			Severity: ep.Severity,
+		}
+		d.entries[ep.Name] = entry
+		d.order = append(d.order, ep.Name)
+	}
+
+	entry.Status = ep.Status
+	entry.Since = ep.Since
+
+	switch kind {
+	case common.KindAlert:
+		entry.Alerts++
+		entry.Error = ep.Error
+		entry.Downtime = 0
+	case common.KindRecovery:
+		// the last error is kept, so the digest shows why the endpoint was down
+		entry.Downtime = ep.Downtime
+	}
+}
+
+// sendDigest sends everything collected in the window as a single event
+func (d *Digest) sendDigest() {
+	d.mux.Lock()
+
+	endpoints := make([]common.Endpoint, 0, len(d.order))
+	for _, name := range d.order {
+		endpoints = append(endpoints, *d.entries[name])
+	}
+
+	d.entries = map[string]*common.Endpoint{}
+	d.order = nil
+	d.flush = nil
+
+	d.mux.Unlock()
+
+	if len(endpoints) == 0 {
+		return
+	}
+
+	event := common.NewEvent(common.KindDigest, endpoints)
+
+	d.logger.Info("sending digest", "event", event.ID, "endpoints", len(endpoints))
+
+	if err := d.notifier.Send(event); err != nil {
+		d.logger.Error("could not send digest", "error", err.Error())
+	}
+}
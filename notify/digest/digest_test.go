@@ -0,0 +1,176 @@
+package digest
+
+import (
+	"github.com/ZeljkoBenovic/go-notify/config"
+	"github.com/ZeljkoBenovic/go-notify/notify/common"
+	"github.com/hashicorp/go-hclog"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recorder is a notifier keeping the events it was sent
+type recorder struct {
+	mux    sync.Mutex
+	events []common.Event
+}
+
+func (r *recorder) Send(event common.Event) error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	r.events = append(r.events, event)
+	return nil
+}
+
+func (r *recorder) SendMockup() error {
+	return nil
+}
+
+func (r *recorder) WithConfig(*config.Config) (common.INotifier, error) {
+	return r, nil
+}
+
+func (r *recorder) sent() []common.Event {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	return append([]common.Event(nil), r.events...)
+}
+
+// endpoint returns an endpoint with the status and severity
+func endpoint(name string, status common.Status, severity common.Severity) common.Endpoint {
+	return common.Endpoint{Name: name, Url: "http://" + name, Status: status, Severity: severity}
+}
+
+// summary lists the endpoints of the event as name:status
+func summary(event common.Event) []string {
+	var endpoints []string
+	for _, ep := range event.Endpoints {
+		endpoints = append(endpoints, ep.Name+":"+string(ep.Status))
+	}
+
+	return endpoints
+}
+
+func TestDigest(t *testing.T) {
+	apiDown := endpoint("api", common.StatusDown, common.SeverityWarning)
+	apiUp := endpoint("api", common.StatusUp, common.SeverityWarning)
+	dbDown := endpoint("db", common.StatusDown, common.SeverityCritical)
+	webUp := endpoint("web", common.StatusUp, common.SeverityInfo)
+
+	tests := []struct {
+		name   string
+		bypass string
+		events []common.Event
+		// wantSent are the endpoints of the events sent right away, wantDigest the ones of the digest
+		wantSent   [][]string
+		wantDigest []string
+		wantAlerts map[string]int
+	}{
+		{
+			name:       "alerts collected",
+			events:     []common.Event{common.NewEvent(common.KindAlert, []common.Endpoint{apiDown, webUp}), common.NewEvent(common.KindAlert, []common.Endpoint{apiDown, dbDown})},
+			wantDigest: []string{"api:DOWN", "db:DOWN"},
+			wantAlerts: map[string]int{"api": 2, "db": 1},
+		},
+		{
+			name:       "recovery replaces the state",
+			events:     []common.Event{common.NewEvent(common.KindAlert, []common.Endpoint{apiDown}), common.NewEvent(common.KindRecovery, []common.Endpoint{apiUp})},
+			wantDigest: []string{"api:UP"},
+			wantAlerts: map[string]int{"api": 1},
+		},
+		{
+			name:       "nothing bypasses the digest without a bypass severity",
+			events:     []common.Event{common.NewEvent(common.KindAlert, []common.Endpoint{dbDown})},
+			wantDigest: []string{"db:DOWN"},
+			wantAlerts: map[string]int{"db": 1},
+		},
+		{
+			name:       "bypass severity",
+			bypass:     "critical",
+			events:     []common.Event{common.NewEvent(common.KindAlert, []common.Endpoint{apiDown, dbDown, webUp})},
+			wantSent:   [][]string{{"db:DOWN", "web:UP"}},
+			wantDigest: []string{"api:DOWN"},
+			wantAlerts: map[string]int{"api": 1},
+		},
+		{
+			name:       "lower bypass severity",
+			bypass:     "warning",
+			events:     []common.Event{common.NewEvent(common.KindAlert, []common.Endpoint{apiDown, dbDown})},
+			wantSent:   [][]string{{"api:DOWN", "db:DOWN"}},
+			wantDigest: nil,
+		},
+		{
+			name:     "other events are not collected",
+			events:   []common.Event{common.NewEvent(common.KindReminder, []common.Endpoint{apiDown})},
+			wantSent: [][]string{{"api:DOWN"}},
+		},
+	}
+
+	for _, tt := range tests {
+		notifier := &recorder{}
+		d := NewDigest(notifier, "test", config.Digest{Window: config.Duration(time.Hour), BypassSeverity: tt.bypass},
+			&config.Config{Logger: hclog.NewNullLogger()})
+
+		for _, e := range tt.events {
+			if err := d.Send(e); err != nil {
+				t.Fatalf("%s: Send error = %v", tt.name, err)
+			}
+		}
+
+		var sent [][]string
+		for _, e := range notifier.sent() {
+			sent = append(sent, summary(e))
+		}
+		if !reflect.DeepEqual(sent, tt.wantSent) {
+			t.Errorf("%s: sent %v, want %v", tt.name, sent, tt.wantSent)
+		}
+
+		d.Flush()
+
+		events := notifier.sent()[len(sent):]
+		if tt.wantDigest == nil {
+			if len(events) != 0 {
+				t.Errorf("%s: digest sent with %v, want none", tt.name, summary(events[0]))
+			}
+			continue
+		}
+
+		if len(events) != 1 || events[0].Kind != common.KindDigest {
+			t.Errorf("%s: sent %d events on flush, want one digest", tt.name, len(events))
+			continue
+		}
+		if got := summary(events[0]); !reflect.DeepEqual(got, tt.wantDigest) {
+			t.Errorf("%s: digest of %v, want %v", tt.name, got, tt.wantDigest)
+		}
+		for _, ep := range events[0].Endpoints {
+			if ep.Alerts != tt.wantAlerts[ep.Name] {
+				t.Errorf("%s: %d alerts of %s, want %d", tt.name, ep.Alerts, ep.Name, tt.wantAlerts[ep.Name])
+			}
+		}
+	}
+}
+
+func TestDigestWindow(t *testing.T) {
+	notifier := &recorder{}
+	d := NewDigest(notifier, "test", config.Digest{Window: config.Duration(20 * time.Millisecond)},
+		&config.Config{Logger: hclog.NewNullLogger()})
+
+	for i := 0; i < 3; i++ {
+		if err := d.Send(common.NewEvent(common.KindAlert, []common.Endpoint{endpoint("api", common.StatusDown, common.SeverityCritical)})); err != nil {
+			t.Fatalf("Send error = %v", err)
+		}
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	sent := notifier.sent()
+	if len(sent) != 1 || sent[0].Kind != common.KindDigest {
+		t.Fatalf("sent %d events after the window, want one digest", len(sent))
+	}
+	if alerts := sent[0].Endpoints[0].Alerts; alerts != 3 {
+		t.Errorf("digest of %d alerts, want 3", alerts)
+	}
+}
@@ -0,0 +1,134 @@
+package notify
+
+import (
+	"fmt"
+	"github.com/ZeljkoBenovic/go-notify/config"
+	"github.com/ZeljkoBenovic/go-notify/notify/common"
+	"github.com/ZeljkoBenovic/go-notify/notify/escalation"
+	"github.com/ZeljkoBenovic/go-notify/notify/routing"
+	"github.com/hashicorp/go-hclog"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// dispatcher sends every endpoint of the event to the channels it belongs to, which are the ones
+// of its escalation policy, or of the matching routes, or the notify_service channel if none match
+type dispatcher struct {
+	escalation *escalation.Escalation
+	logger     hclog.Logger
+
+	// mux guards the channels and routes, which are replaced on reload
+	mux            sync.RWMutex
+	channels       map[string]common.INotifier
+	names          []string
+	defaultChannel string
+	router         *routing.Router
+}
+
+// set replaces the channels and routes of the dispatcher
+func (d *dispatcher) set(channels map[string]common.INotifier, defaultChannel string, router *routing.Router) {
+	names := make([]string, 0, len(channels))
+	for name := range channels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	d.channels = channels
+	d.names = names
+	d.defaultChannel = defaultChannel
+	d.router = router
+}
+
+func (d *dispatcher) Send(event common.Event) error {
+	d.mux.RLock()
+	defer d.mux.RUnlock()
+
+	targets := map[string][]common.Endpoint{}
+
+	for _, ep := range event.Affected() {
+		var (
+			channels []string
+			ok       bool
+		)
+
+		if event.Kind == common.KindRecovery {
+			channels, ok = d.escalation.Resolve(ep)
+		} else {
+			channels, ok = d.escalation.Channels(ep)
+		}
+
+		if !ok {
+			if channels = d.router.Channels(ep); len(channels) == 0 {
+				channels = []string{d.defaultChannel}
+			}
+		}
+
+		for _, channel := range channels {
+			targets[channel] = append(targets[channel], ep)
+		}
+	}
+
+	var errs []string
+
+	for _, name := range d.names {
+		if len(targets[name]) == 0 {
+			continue
+		}
+
+		out := event
+		out.Endpoints = targets[name]
+
+		// keep the healthy endpoints of an alert, so the message still shows how many services are checked
+		if event.Kind == common.KindAlert {
+			for _, ep := range event.Endpoints {
+				if ep.Status == common.StatusUp {
+					out.Endpoints = append(out.Endpoints, ep)
+				}
+			}
+		}
+
+		d.logger.Debug("dispatching event", "event", event.ID, "channel", name, "endpoints", len(targets[name]))
+
+		if err := d.channels[name].Send(out); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", name, err.Error()))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("could not notify all channels: %s", strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+func (d *dispatcher) SendMockup() error {
+	d.mux.RLock()
+	defer d.mux.RUnlock()
+
+	for _, name := range d.names {
+		if err := d.channels[name].SendMockup(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *dispatcher) WithConfig(config *config.Config) (common.INotifier, error) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	for name, channel := range d.channels {
+		notifier, err := channel.WithConfig(config)
+		if err != nil {
+			return nil, fmt.Errorf("could not configure channel %s: %w", name, err)
+		}
+		d.channels[name] = notifier
+	}
+
+	return d, nil
+}
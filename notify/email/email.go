@@ -5,12 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"github.com/ZeljkoBenovic/go-notify/config"
-	monitorHttp "github.com/ZeljkoBenovic/go-notify/monitor/http"
 	"github.com/ZeljkoBenovic/go-notify/notify/common"
 	"github.com/hashicorp/go-hclog"
 	"gopkg.in/gomail.v2"
 	"html/template"
-	"reflect"
 )
 
 type email struct {
@@ -21,7 +19,7 @@ type email struct {
 	smtpAuthEnabled                         bool
 	smtpServer                              string
 	smtpPort                                uint64
-	EndpointsData                           []monitorHttp.HttpEndpoints
+	Event                                   common.Event
 
 	ServiceName []config.Monitor
 	smtpMessage *gomail.Message
@@ -51,17 +49,10 @@ func (e *email) WithConfig(config *config.Config) (common.INotifier, error) {
 	return e, nil
 }
 
-func (e email) Send(monitor interface{}) error {
-	//TODO: different reflections for different endpoint structs ( http, json, ... )
-	httpType := reflect.ValueOf(monitor)
-	httpKind := reflect.Indirect(httpType).Kind()
-	if httpKind != reflect.Struct {
-		return fmt.Errorf("type Struct must be passed in Send function")
-	}
-
+func (e email) Send(event common.Event) error {
 	e.logger.Debug("Send function")
 
-	e.EndpointsData = reflect.Indirect(httpType).FieldByName("Http").Interface().([]monitorHttp.HttpEndpoints)
+	e.Event = event
 
 	if err := e.createMessage(); err != nil {
 		return err
@@ -104,7 +95,12 @@ func (e *email) createMessage() error {
 		e.smtpMessage.SetHeader("Bcc", bcc)
 	}
 
-	e.smtpMessage.SetHeader("Subject", e.subject)
+	// the configured subject is used for alerts, other events are described by their title
+	subject := e.subject
+	if e.Event.Kind != common.KindAlert {
+		subject = e.Event.Title()
+	}
+	e.smtpMessage.SetHeader("Subject", subject)
 
 	if e.body == "" {
 		if err := e.createHtmlTemplate(); err != nil {
@@ -119,15 +115,12 @@ func (e *email) createMessage() error {
 func (e *email) createHtmlTemplate() error {
 	buff := new(bytes.Buffer)
 
-	t, err := template.New("default.html").Funcs(template.FuncMap{
-		"toString": func(url string) monitorHttp.Url {
-			return monitorHttp.Url(url)
-		}}).ParseFiles("notify/email/templates/default.html")
+	t, err := template.New("default.html").ParseFiles("notify/email/templates/default.html")
 	if err != nil {
 		return fmt.Errorf("could not parse template %w", err)
 	}
 
-	if err := t.Execute(buff, e.EndpointsData); err != nil {
+	if err := t.Execute(buff, e.Event); err != nil {
 		return fmt.Errorf("could not execute %w", err)
 	}
 
@@ -0,0 +1,149 @@
+package escalation
+
+import (
+	"github.com/ZeljkoBenovic/go-notify/config"
+	"github.com/ZeljkoBenovic/go-notify/notify/common"
+	"github.com/ZeljkoBenovic/go-notify/notify/incident"
+	"github.com/hashicorp/go-hclog"
+	"sync"
+	"time"
+)
+
+// tier is a single step of an escalation policy
+type tier struct {
+	after  time.Duration
+	notify []string
+}
+
+// Escalation decides which notification services are told about an incident of a monitor with an
+// escalation policy. Every tier is added once the incident has been open for the time set on it,
+// and the tiers already reached keep getting the alerts until the incident is resolved.
+// Acknowledged incidents are not escalated any further, and their alerts are not sent to any tier,
+// only their recovery is.
+type Escalation struct {
+	registry *incident.Registry
+
+	policies map[string][]tier
+	monitors map[string]string
+
+	logger hclog.Logger
+
+	mux     sync.Mutex
+	reached map[string]int
+}
+
+// NewEscalation creates the escalation policies from the config
+func NewEscalation(registry *incident.Registry, config *config.Config) *Escalation {
+	e := &Escalation{
+		registry: registry,
+		logger:   config.Logger.Named("escalation"),
+		reached:  map[string]int{},
+	}
+
+	e.Reload(config)
+
+	return e
+}
+
+// Reload replaces the escalation policies with the ones of the config,
+// the open incidents keep the tiers they already reached
+func (e *Escalation) Reload(config *config.Config) {
+	policies := map[string][]tier{}
+	for name, tiers := range config.EscalationPolicies {
+		for _, t := range tiers {
+			policies[name] = append(policies[name], tier{
+				after:  time.Duration(t.After),
+				notify: t.Notify,
+			})
+		}
+	}
+
+	monitors := map[string]string{}
+	for _, mon := range config.MonitoredServices.Http {
+		if mon.Escalation != "" {
+			monitors[mon.Name] = mon.Escalation
+		}
+	}
+
+	e.mux.Lock()
+	defer e.mux.Unlock()
+
+	e.policies = policies
+	e.monitors = monitors
+}
+
+// Channels returns the notification services to alert about the failing endpoint,
+// ok is false if the monitor of the endpoint has no escalation policy
+func (e *Escalation) Channels(ep common.Endpoint) (channels []string, ok bool) {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+
+	name, ok := e.monitors[ep.Name]
+	if !ok {
+		return nil, false
+	}
+	tiers := e.policies[name]
+
+	var elapsed time.Duration
+	open, found := e.registry.Get(ep.Name)
+	if found {
+		elapsed = time.Since(open.Since)
+	}
+
+	reached, seen := e.reached[ep.Incident]
+	if !seen {
+		reached = -1
+	}
+
+	// the tiers already reached are kept, so they are told about the recovery
+	if open.Acknowledged() {
+		e.logger.Debug("incident acknowledged, no tier alerted", "endpoint", ep.Name, "policy", name)
+		return nil, true
+	}
+
+	for reached+1 < len(tiers) && tiers[reached+1].after <= elapsed {
+		reached++
+		e.logger.Info("incident escalated", "endpoint", ep.Name, "policy", name, "tier", reached+1)
+	}
+
+	e.reached[ep.Incident] = reached
+
+	return notified(tiers, reached), true
+}
+
+// Resolve returns the notification services that were alerted about the incident of the recovered
+// endpoint and forgets it, ok is false if the monitor of the endpoint has no escalation policy
+func (e *Escalation) Resolve(ep common.Endpoint) (channels []string, ok bool) {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+
+	name, ok := e.monitors[ep.Name]
+	if !ok {
+		return nil, false
+	}
+
+	reached, seen := e.reached[ep.Incident]
+	if !seen {
+		return nil, true
+	}
+	delete(e.reached, ep.Incident)
+
+	return notified(e.policies[name], reached), true
+}
+
+// notified returns the notification services of all tiers up to the reached one, without duplicates
+func notified(tiers []tier, reached int) []string {
+	var channels []string
+	seen := map[string]bool{}
+
+	for i := 0; i <= reached && i < len(tiers); i++ {
+		for _, channel := range tiers[i].notify {
+			if !seen[channel] {
+				seen[channel] = true
+				channels = append(channels, channel)
+			}
+		}
+	}
+
+	return channels
+}
@@ -0,0 +1,85 @@
+package escalation
+
+import (
+	"github.com/ZeljkoBenovic/go-notify/config"
+	"github.com/ZeljkoBenovic/go-notify/notify/common"
+	"github.com/ZeljkoBenovic/go-notify/notify/incident"
+	"github.com/hashicorp/go-hclog"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func newTestConfig() *config.Config {
+	conf := &config.Config{
+		Logger: hclog.NewNullLogger(),
+		EscalationPolicies: map[string][]config.EscalationTier{
+			"night": {
+				{After: 0, Notify: []string{"ops"}},
+				{After: config.Duration(30 * time.Minute), Notify: []string{"oncall", "ops"}},
+				{After: config.Duration(2 * time.Hour), Notify: []string{"manager"}},
+			},
+		},
+	}
+	conf.MonitoredServices.Http = []config.Monitor{{Name: "api", Escalation: "night"}, {Name: "web"}}
+
+	return conf
+}
+
+func TestEscalationChannels(t *testing.T) {
+	tests := []struct {
+		name         string
+		endpoint     string
+		open         time.Duration
+		acknowledged bool
+		want         []string
+		wantOk       bool
+	}{
+		{name: "no escalation policy", endpoint: "web", wantOk: false},
+		{name: "first tier", endpoint: "api", open: time.Minute, want: []string{"ops"}, wantOk: true},
+		{name: "second tier, without duplicates", endpoint: "api", open: time.Hour, want: []string{"ops", "oncall"}, wantOk: true},
+		{name: "all tiers", endpoint: "api", open: 3 * time.Hour, want: []string{"ops", "oncall", "manager"}, wantOk: true},
+		{name: "acknowledged", endpoint: "api", open: 3 * time.Hour, acknowledged: true, want: nil, wantOk: true},
+	}
+
+	for _, tt := range tests {
+		registry := incident.NewRegistry()
+		e := NewEscalation(registry, newTestConfig())
+
+		open := registry.Open(common.Endpoint{Name: tt.endpoint, Since: time.Now().Add(-tt.open)})
+		if tt.acknowledged {
+			if _, err := registry.Acknowledge(open.ID, "me"); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		got, ok := e.Channels(common.Endpoint{Name: tt.endpoint, Status: common.StatusDown, Incident: open.ID})
+		if ok != tt.wantOk || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Channels = %v, %v, want %v, %v", tt.name, got, ok, tt.want, tt.wantOk)
+		}
+	}
+}
+
+func TestEscalationResolve(t *testing.T) {
+	registry := incident.NewRegistry()
+	e := NewEscalation(registry, newTestConfig())
+
+	open := registry.Open(common.Endpoint{Name: "api", Since: time.Now().Add(-time.Hour)})
+	ep := common.Endpoint{Name: "api", Status: common.StatusDown, Incident: open.ID}
+	e.Channels(ep)
+
+	// the tiers reached are kept after the acknowledgement, so they are told about the recovery
+	if _, err := registry.Acknowledge(open.ID, "me"); err != nil {
+		t.Fatal(err)
+	}
+	e.Channels(ep)
+
+	ep.Status = common.StatusUp
+	if got, ok := e.Resolve(ep); !ok || !reflect.DeepEqual(got, []string{"ops", "oncall"}) {
+		t.Errorf("Resolve = %v, %v, want [ops oncall], true", got, ok)
+	}
+
+	if got, ok := e.Resolve(ep); !ok || got != nil {
+		t.Errorf("second Resolve = %v, %v, want [], true", got, ok)
+	}
+}
@@ -0,0 +1,249 @@
+package exec
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ZeljkoBenovic/go-notify/config"
+	"github.com/ZeljkoBenovic/go-notify/notify/common"
+	"github.com/hashicorp/go-hclog"
+	"io"
+	"os"
+	osExec "os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// outputWaitDelay is how long the output is still read after the command exited,
+// the processes it started that keep the output open longer are killed
+const outputWaitDelay = time.Second
+
+type exec struct {
+	command string
+	args    []string
+	timeout time.Duration
+
+	logger hclog.Logger
+}
+
+func (e *exec) WithConfig(config *config.Config) (common.INotifier, error) {
+	if config.Services.Exec.Command == "" {
+		return nil, errors.New("command for exec not defined")
+	}
+
+	if config.Services.Exec.Timeout == 0 {
+		return nil, errors.New("timeout for exec must be greater than zero")
+	}
+
+	e.command = config.Services.Exec.Command
+	e.args = config.Services.Exec.Args
+	e.timeout = time.Duration(config.Services.Exec.Timeout)
+	e.logger = config.Logger.Named("exec")
+
+	e.logger.Debug("exec config successfully initialized")
+	return e, nil
+}
+
+func (e exec) Send(event common.Event) error {
+	e.logger.Debug("Send function")
+
+	stdin, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("could not marshal event: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+	defer cancel()
+
+	stdout := newLogWriter(e.logger, hclog.Info, "stdout")
+	stderr := newLogWriter(e.logger, hclog.Warn, "stderr")
+
+	cmd := osExec.CommandContext(ctx, e.command, e.args...)
+	cmd.Env = append(os.Environ(), environment(event)...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	setProcessGroup(cmd)
+
+	if err := run(ctx, cmd, stdout, stderr); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("command %s did not finish in %s", e.command, e.timeout)
+		}
+
+		return fmt.Errorf("command %s failed: %w", e.command, err)
+	}
+
+	e.logger.Info("exec notification successfully sent", "command", e.command)
+	return nil
+}
+
+func (e exec) SendMockup() error {
+	fmt.Println("Sending...")
+
+	return nil
+}
+
+func NotifierFactory() common.INotifier {
+	return &exec{}
+}
+
+// run runs the command and copies its output to the provided log writers.
+// The output pipes are handled here, instead of by os/exec, so that processes started
+// by the command which keep the output open can not block the send. They are killed
+// once the command timed out, or shortly after the command exited.
+func run(ctx context.Context, cmd *osExec.Cmd, stdout, stderr *logWriter) error {
+	stdoutR, stdoutW, err := os.Pipe()
+	if err != nil {
+		return fmt.Errorf("could not create stdout pipe: %w", err)
+	}
+
+	stderrR, stderrW, err := os.Pipe()
+	if err != nil {
+		_ = stdoutR.Close()
+		_ = stdoutW.Close()
+		return fmt.Errorf("could not create stderr pipe: %w", err)
+	}
+
+	cmd.Stdout = stdoutW
+	cmd.Stderr = stderrW
+
+	startErr := cmd.Start()
+
+	// the command holds its own copies of the write ends
+	_ = stdoutW.Close()
+	_ = stderrW.Close()
+
+	wg := sync.WaitGroup{}
+	for reader, writer := range map[*os.File]*logWriter{stdoutR: stdout, stderrR: stderr} {
+		wg.Add(1)
+
+		go func(reader *os.File, writer *logWriter) {
+			defer wg.Done()
+
+			_, _ = io.Copy(writer, reader)
+			writer.Flush()
+		}(reader, writer)
+	}
+
+	copied := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(copied)
+	}()
+
+	err = startErr
+	if err == nil {
+		err = cmd.Wait()
+
+		select {
+		case <-copied:
+		case <-ctx.Done():
+			killProcessGroup(cmd)
+		case <-time.After(outputWaitDelay):
+			killProcessGroup(cmd)
+		}
+	}
+
+	_ = stdoutR.Close()
+	_ = stderrR.Close()
+
+	<-copied
+
+	return err
+}
+
+// environment returns the event data as GONOTIFY_* environment variables.
+// Single value variables hold the data of the first endpoint the event is about.
+func environment(event common.Event) []string {
+	failing := event.Failing()
+	affected := event.Affected()
+
+	urls := make([]string, 0, len(affected))
+	for _, ep := range affected {
+		urls = append(urls, ep.Url)
+	}
+
+	env := []string{
+		"GONOTIFY_EVENT_ID=" + event.ID,
+		"GONOTIFY_KIND=" + string(event.Kind),
+		"GONOTIFY_TIME=" + event.Time.Format(time.RFC3339),
+		"GONOTIFY_TITLE=" + event.Title(),
+		"GONOTIFY_SEVERITY=" + string(event.Severity()),
+		"GONOTIFY_FAILING=" + strconv.Itoa(len(failing)),
+		"GONOTIFY_TOTAL=" + strconv.Itoa(len(event.Endpoints)),
+		"GONOTIFY_URLS=" + strings.Join(urls, " "),
+		"GONOTIFY_SUPPRESSED=" + strconv.FormatUint(event.Suppressed, 10),
+	}
+
+	if len(affected) > 0 {
+		env = append(env,
+			"GONOTIFY_NAME="+affected[0].Name,
+			"GONOTIFY_URL="+affected[0].Url,
+			"GONOTIFY_STATUS="+string(affected[0].Status),
+			"GONOTIFY_ERROR="+affected[0].Error,
+		)
+	}
+
+	return env
+}
+
+// logWriter writes every line of the command output to the logger
+type logWriter struct {
+	logger hclog.Logger
+	level  hclog.Level
+	stream string
+
+	mux  sync.Mutex
+	buff bytes.Buffer
+}
+
+func newLogWriter(logger hclog.Logger, level hclog.Level, stream string) *logWriter {
+	return &logWriter{
+		logger: logger,
+		level:  level,
+		stream: stream,
+	}
+}
+
+func (w *logWriter) Write(p []byte) (int, error) {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+
+	w.buff.Write(p)
+
+	for {
+		line, err := w.buff.ReadString('\n')
+		if err != nil {
+			// put back the incomplete line until the rest of it is written
+			w.buff.Reset()
+			w.buff.WriteString(line)
+			break
+		}
+
+		w.log(line)
+	}
+
+	return len(p), nil
+}
+
+// Flush logs the remaining output that did not end with a new line
+func (w *logWriter) Flush() {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+
+	if w.buff.Len() > 0 {
+		w.log(w.buff.String())
+		w.buff.Reset()
+	}
+}
+
+func (w *logWriter) log(line string) {
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" {
+		return
+	}
+
+	w.logger.Log(w.level, "command output", "stream", w.stream, "line", line)
+}
@@ -0,0 +1,14 @@
+//go:build windows || plan9
+// +build windows plan9
+
+package exec
+
+import (
+	osExec "os/exec"
+)
+
+// setProcessGroup does nothing, the processes started by the command are not tracked on this platform
+func setProcessGroup(cmd *osExec.Cmd) {}
+
+// killProcessGroup does nothing, the output pipes are closed instead
+func killProcessGroup(cmd *osExec.Cmd) {}
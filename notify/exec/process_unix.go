@@ -0,0 +1,21 @@
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package exec
+
+import (
+	osExec "os/exec"
+	"syscall"
+)
+
+// setProcessGroup starts the command in its own process group, so the processes it starts can be killed with it
+func setProcessGroup(cmd *osExec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// killProcessGroup kills the processes of the command's process group that are still running
+func killProcessGroup(cmd *osExec.Cmd) {
+	if cmd.Process != nil {
+		_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
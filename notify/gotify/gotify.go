@@ -0,0 +1,139 @@
+package gotify
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ZeljkoBenovic/go-notify/config"
+	"github.com/ZeljkoBenovic/go-notify/notify/common"
+	"github.com/hashicorp/go-hclog"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const requestTimeout = 30 * time.Second
+
+// gotify message priorities
+const (
+	priorityLow    = 2
+	priorityNormal = 5
+	priorityHigh   = 8
+)
+
+type gotify struct {
+	server   string
+	appToken string
+	tags     []string
+
+	client *http.Client
+	logger hclog.Logger
+}
+
+// message is the JSON body accepted by the gotify message endpoint
+type message struct {
+	Title    string                 `json:"title"`
+	Message  string                 `json:"message"`
+	Priority int                    `json:"priority"`
+	Extras   map[string]interface{} `json:"extras,omitempty"`
+}
+
+func (g *gotify) WithConfig(config *config.Config) (common.INotifier, error) {
+	if config.Services.Gotify.Server == "" {
+		return nil, errors.New("server for gotify not defined")
+	}
+
+	if config.Services.Gotify.AppToken == "" {
+		return nil, errors.New("app token for gotify not defined")
+	}
+
+	g.server = strings.TrimRight(config.Services.Gotify.Server, "/")
+	g.appToken = config.Services.Gotify.AppToken
+	g.tags = config.Services.Gotify.Tags
+	g.logger = config.Logger.Named("gotify")
+
+	g.logger.Debug("gotify config successfully initialized")
+	return g, nil
+}
+
+func (g gotify) Send(event common.Event) error {
+	g.logger.Debug("Send function")
+
+	severity := event.Severity()
+
+	msg := message{
+		Title:    event.Title(),
+		Message:  event.Text(),
+		Priority: priority(severity),
+		Extras: map[string]interface{}{
+			"client::display": map[string]string{"contentType": "text/plain"},
+			// gotify has no notion of tags, so they are passed in a custom extras namespace
+			"gonotify::metadata": map[string]interface{}{
+				"severity": severity,
+				"tags":     g.tags,
+			},
+		},
+	}
+
+	if clickUrl := event.Url(); clickUrl != "" {
+		msg.Extras["client::notification"] = map[string]interface{}{
+			"click": map[string]string{"url": clickUrl},
+		}
+	}
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("could not marshal gotify message: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, g.server+"/message", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("could not create gotify request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Gotify-Key", g.appToken)
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("could not send gotify message: %w", err)
+	}
+	defer func(Body io.ReadCloser) {
+		_ = Body.Close()
+	}(resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		respBody, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("gotify server returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	g.logger.Info("gotify notification successfully sent")
+	return nil
+}
+
+func (g gotify) SendMockup() error {
+	fmt.Println("Sending...")
+
+	return nil
+}
+
+func NotifierFactory() common.INotifier {
+	return &gotify{
+		client: &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// priority maps the event severity to the gotify message priority
+func priority(severity common.Severity) int {
+	switch severity {
+	case common.SeverityInfo:
+		return priorityLow
+	case common.SeverityWarning:
+		return priorityNormal
+	default:
+		return priorityHigh
+	}
+}
@@ -0,0 +1,138 @@
+package incident
+
+import (
+	"errors"
+	"github.com/ZeljkoBenovic/go-notify/notify/common"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned for incidents that do not exist or are already resolved
+var ErrNotFound = errors.New("incident not found")
+
+// Incident is an outage of a single endpoint, open from the first failed check until it recovers
+type Incident struct {
+	ID             string     `json:"id"`
+	Endpoint       string     `json:"endpoint"`
+	Url            string     `json:"url"`
+	Since          time.Time  `json:"since"`
+	AcknowledgedBy string     `json:"acknowledged_by,omitempty"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty"`
+}
+
+// Acknowledged returns true if someone already took the incident over
+func (i Incident) Acknowledged() bool {
+	return i.AcknowledgedAt != nil
+}
+
+// Registry keeps track of the open incidents, keyed by the endpoint name
+type Registry struct {
+	mux  sync.RWMutex
+	open map[string]*Incident
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		open: map[string]*Incident{},
+	}
+}
+
+// Open returns the open incident of the endpoint, creating it if the endpoint was not already down
+func (r *Registry) Open(ep common.Endpoint) Incident {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if incident, ok := r.open[ep.Name]; ok {
+		return *incident
+	}
+
+	since := ep.Since
+	if since.IsZero() {
+		since = time.Now()
+	}
+
+	incident := &Incident{
+		ID:       common.NewID(),
+		Endpoint: ep.Name,
+		Url:      ep.Url,
+		Since:    since,
+	}
+	r.open[ep.Name] = incident
+
+	return *incident
+}
+
+// Resolve closes the incident of the endpoint
+func (r *Registry) Resolve(endpoint string) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	delete(r.open, endpoint)
+}
+
+// Get returns the open incident of the endpoint
+func (r *Registry) Get(endpoint string) (Incident, bool) {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+
+	incident, ok := r.open[endpoint]
+	if !ok {
+		return Incident{}, false
+	}
+
+	return *incident, true
+}
+
+// Find returns the open incident with the provided ID
+func (r *Registry) Find(id string) (Incident, error) {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+
+	for _, incident := range r.open {
+		if incident.ID == id {
+			return *incident, nil
+		}
+	}
+
+	return Incident{}, ErrNotFound
+}
+
+// Acknowledge marks the incident with the provided ID as taken over by the provided person
+func (r *Registry) Acknowledge(id, by string) (Incident, error) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	for _, incident := range r.open {
+		if incident.ID != id {
+			continue
+		}
+
+		if !incident.Acknowledged() {
+			incident.AcknowledgedBy = by
+			now := time.Now()
+			incident.AcknowledgedAt = &now
+		}
+
+		return *incident, nil
+	}
+
+	return Incident{}, ErrNotFound
+}
+
+// List returns all open incidents, oldest first
+func (r *Registry) List() []Incident {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+
+	incidents := make([]Incident, 0, len(r.open))
+	for _, incident := range r.open {
+		incidents = append(incidents, *incident)
+	}
+
+	sort.Slice(incidents, func(i, j int) bool {
+		return incidents[i].Since.Before(incidents[j].Since)
+	})
+
+	return incidents
+}
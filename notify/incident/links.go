@@ -0,0 +1,45 @@
+package incident
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/ZeljkoBenovic/go-notify/config"
+	"net/url"
+)
+
+// Links creates and verifies the signed acknowledge links of the incidents,
+// so only the people that received the notification can acknowledge it
+type Links struct {
+	baseUrl string
+	secret  []byte
+}
+
+// NewLinks returns the link signer of the api, or nil if the api is disabled
+func NewLinks(config *config.Config) *Links {
+	if config.Api.Listen == "" {
+		return nil
+	}
+
+	return &Links{
+		baseUrl: config.Api.ExternalUrl,
+		secret:  []byte(config.Api.Secret),
+	}
+}
+
+// Ack returns the signed acknowledge link of the incident
+func (l *Links) Ack(id string) string {
+	return l.baseUrl + "/ack/" + url.PathEscape(id) + "?sig=" + l.sign(id)
+}
+
+// Verify returns true if the signature of the acknowledge link is valid
+func (l *Links) Verify(id, signature string) bool {
+	return hmac.Equal([]byte(l.sign(id)), []byte(signature))
+}
+
+func (l *Links) sign(id string) string {
+	mac := hmac.New(sha256.New, l.secret)
+	mac.Write([]byte("ack:" + id))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
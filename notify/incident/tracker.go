@@ -0,0 +1,65 @@
+package incident
+
+import (
+	"github.com/ZeljkoBenovic/go-notify/config"
+	"github.com/ZeljkoBenovic/go-notify/notify/common"
+)
+
+// Tracker opens an incident for every failing endpoint and resolves it once the endpoint recovers.
+// The incident ID, and the acknowledge link if the api is enabled, are added to the endpoints
+// before the event is passed on to the notifier.
+type Tracker struct {
+	notifier common.INotifier
+	registry *Registry
+	links    *Links
+}
+
+// NewTracker wraps the notifier with the incident tracking of the provided registry,
+// links can be nil if the incidents can not be acknowledged
+func NewTracker(notifier common.INotifier, registry *Registry, links *Links) *Tracker {
+	return &Tracker{
+		notifier: notifier,
+		registry: registry,
+		links:    links,
+	}
+}
+
+// Send updates the incidents of the endpoints in the event and forwards it
+func (t *Tracker) Send(event common.Event) error {
+	endpoints := make([]common.Endpoint, len(event.Endpoints))
+	copy(endpoints, event.Endpoints)
+
+	for i, ep := range endpoints {
+		switch {
+		case ep.Status != common.StatusUp:
+			incident := t.registry.Open(ep)
+			endpoints[i].Incident = incident.ID
+			if t.links != nil && !incident.Acknowledged() {
+				endpoints[i].AckUrl = t.links.Ack(incident.ID)
+			}
+		case event.Kind == common.KindRecovery:
+			if open, ok := t.registry.Get(ep.Name); ok {
+				endpoints[i].Incident = open.ID
+			}
+			t.registry.Resolve(ep.Name)
+		}
+	}
+
+	event.Endpoints = endpoints
+
+	return t.notifier.Send(event)
+}
+
+func (t *Tracker) SendMockup() error {
+	return t.notifier.SendMockup()
+}
+
+func (t *Tracker) WithConfig(config *config.Config) (common.INotifier, error) {
+	notifier, err := t.notifier.WithConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	t.notifier = notifier
+
+	return t, nil
+}
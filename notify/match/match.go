@@ -0,0 +1,65 @@
+package match
+
+import (
+	"github.com/ZeljkoBenovic/go-notify/config"
+	"regexp"
+	"strings"
+)
+
+// Matcher is a compiled config.Matcher, a matcher without conditions matches all monitors
+type Matcher struct {
+	names  []*regexp.Regexp
+	labels map[string]string
+	url    *regexp.Regexp
+}
+
+func New(conf config.Matcher) *Matcher {
+	m := &Matcher{
+		labels: conf.Labels,
+	}
+
+	for _, name := range conf.Names {
+		m.names = append(m.names, glob(name))
+	}
+
+	if conf.Url != "" {
+		m.url = glob(conf.Url)
+	}
+
+	return m
+}
+
+// Matches returns true if the monitor matches all conditions
+func (m *Matcher) Matches(name, url string, labels map[string]string) bool {
+	if len(m.names) > 0 {
+		found := false
+		for _, pattern := range m.names {
+			if pattern.MatchString(name) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	for key, value := range m.labels {
+		if labels[key] != value {
+			return false
+		}
+	}
+
+	return m.url == nil || m.url.MatchString(url)
+}
+
+// glob compiles a pattern where * matches any number of characters
+func glob(pattern string) *regexp.Regexp {
+	parts := strings.Split(pattern, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+
+	return regexp.MustCompile("^" + strings.Join(parts, ".*") + "$")
+}
@@ -0,0 +1,127 @@
+package matrix
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ZeljkoBenovic/go-notify/config"
+	"github.com/ZeljkoBenovic/go-notify/notify/common"
+	"github.com/hashicorp/go-hclog"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const requestTimeout = 30 * time.Second
+
+type matrix struct {
+	homeserver  string
+	accessToken string
+	roomID      string
+
+	client *http.Client
+	logger hclog.Logger
+}
+
+// roomMessage is the content of the m.room.message event
+type roomMessage struct {
+	MsgType       string `json:"msgtype"`
+	Body          string `json:"body"`
+	Format        string `json:"format"`
+	FormattedBody string `json:"formatted_body"`
+}
+
+// errorResponse is the standard error returned by the Matrix client-server API
+type errorResponse struct {
+	ErrCode string `json:"errcode"`
+	Error   string `json:"error"`
+}
+
+func (m *matrix) WithConfig(config *config.Config) (common.INotifier, error) {
+	if config.Services.Matrix.Homeserver == "" {
+		return nil, errors.New("homeserver for Matrix not defined")
+	}
+
+	if config.Services.Matrix.AccessToken == "" {
+		return nil, errors.New("access token for Matrix not defined")
+	}
+
+	if config.Services.Matrix.RoomID == "" {
+		return nil, errors.New("room id for Matrix not defined")
+	}
+
+	m.homeserver = strings.TrimRight(config.Services.Matrix.Homeserver, "/")
+	m.accessToken = config.Services.Matrix.AccessToken
+	m.roomID = config.Services.Matrix.RoomID
+	m.logger = config.Logger.Named("matrix")
+
+	m.logger.Debug("matrix config successfully initialized")
+	return m, nil
+}
+
+func (m matrix) Send(event common.Event) error {
+	m.logger.Debug("Send function")
+
+	body, err := json.Marshal(roomMessage{
+		MsgType:       "m.text",
+		Body:          event.Text(),
+		Format:        "org.matrix.custom.html",
+		FormattedBody: event.HTML(),
+	})
+	if err != nil {
+		return fmt.Errorf("could not marshal matrix message: %w", err)
+	}
+
+	// the event ID is used as the transaction ID so the homeserver
+	// will not post the same event twice if the request is retried
+	reqUrl := fmt.Sprintf(
+		"%s/_matrix/client/v3/rooms/%s/send/m.room.message/%s",
+		m.homeserver, url.PathEscape(m.roomID), url.PathEscape(event.ID),
+	)
+
+	req, err := http.NewRequest(http.MethodPut, reqUrl, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("could not create matrix request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+m.accessToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("could not send matrix message: %w", err)
+	}
+	defer func(Body io.ReadCloser) {
+		_ = Body.Close()
+	}(resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		respBody, _ := ioutil.ReadAll(resp.Body)
+
+		matrixErr := errorResponse{}
+		if err := json.Unmarshal(respBody, &matrixErr); err == nil && matrixErr.ErrCode != "" {
+			return fmt.Errorf("matrix homeserver returned status %d: %s %s", resp.StatusCode, matrixErr.ErrCode, matrixErr.Error)
+		}
+
+		return fmt.Errorf("matrix homeserver returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	m.logger.Info("matrix notification successfully sent", "room", m.roomID)
+	return nil
+}
+
+func (m matrix) SendMockup() error {
+	fmt.Println("Sending...")
+
+	return nil
+}
+
+func NotifierFactory() common.INotifier {
+	return &matrix{
+		client: &http.Client{Timeout: requestTimeout},
+	}
+}
@@ -1,37 +1,330 @@
 package notify
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/ZeljkoBenovic/go-notify/config"
 	"github.com/ZeljkoBenovic/go-notify/notify/common"
+	"github.com/ZeljkoBenovic/go-notify/notify/delivery"
+	"github.com/ZeljkoBenovic/go-notify/notify/digest"
 	"github.com/ZeljkoBenovic/go-notify/notify/email"
+	"github.com/ZeljkoBenovic/go-notify/notify/escalation"
+	"github.com/ZeljkoBenovic/go-notify/notify/exec"
+	"github.com/ZeljkoBenovic/go-notify/notify/gotify"
+	"github.com/ZeljkoBenovic/go-notify/notify/incident"
+	"github.com/ZeljkoBenovic/go-notify/notify/matrix"
+	"github.com/ZeljkoBenovic/go-notify/notify/ntfy"
+	"github.com/ZeljkoBenovic/go-notify/notify/policy"
+	"github.com/ZeljkoBenovic/go-notify/notify/reminder"
+	"github.com/ZeljkoBenovic/go-notify/notify/routing"
+	"github.com/ZeljkoBenovic/go-notify/notify/silence"
 	"github.com/ZeljkoBenovic/go-notify/notify/slack"
+	"github.com/ZeljkoBenovic/go-notify/notify/sms"
+	"github.com/ZeljkoBenovic/go-notify/notify/syslog"
+	"time"
 )
 
 // available notifier service names
 const (
-	slackType common.NotifierType = "slack"
-	emailType common.NotifierType = "email"
+	slackType  common.NotifierType = "slack"
+	emailType  common.NotifierType = "email"
+	matrixType common.NotifierType = "matrix"
+	ntfyType   common.NotifierType = "ntfy"
+	gotifyType common.NotifierType = "gotify"
+	smsType    common.NotifierType = "sms"
+	execType   common.NotifierType = "exec"
+	syslogType common.NotifierType = "syslog"
 )
 
 // availableNotifiers creates a map of all available NotifierFactories
 var availableNotifiers = map[common.NotifierType]common.NotifierFactory{
-	emailType: email.NotifierFactory,
-	slackType: slack.NotifierFactory,
+	emailType:  email.NotifierFactory,
+	slackType:  slack.NotifierFactory,
+	matrixType: matrix.NotifierFactory,
+	ntfyType:   ntfy.NotifierFactory,
+	gotifyType: gotify.NotifierFactory,
+	smsType:    sms.NotifierFactory,
+	execType:   exec.NotifierFactory,
+	syslogType: syslog.NotifierFactory,
 }
 
-// NewNotifier returns an instance of the notifier service
-func NewNotifier(config *config.Config) (common.INotifier, error) {
-	notifierFactory, ok := availableNotifiers[common.NotifierType(config.NotifyService)]
+// Notifier is the notifier service, sending the events through the incident tracking, the maintenance windows
+// and silences, and the escalation policies and routes to the channels.
+// Besides the notify_service, a channel is created for every service used by the escalation policies and routes.
+type Notifier struct {
+	common.INotifier
+
+	registry   *incident.Registry
+	silencer   *silence.Silencer
+	dispatcher *dispatcher
+
+	channels map[string]*channel
+}
+
+// channel is a notification channel with the settings it was created from, so it is only replaced
+// on reload if they change. The settings of the monitors and the replay interval are applied to
+// the kept channels with reload.
+type channel struct {
+	common.INotifier
+	spec   string
+	close  func()
+	reload func(conf *config.Config)
+	// wait waits until the events queued before close are delivered
+	wait func()
+}
+
+// NewNotifier returns an instance of the notifier service, with the incident tracking of the registry
+// and the maintenance windows and silences in front of it
+func NewNotifier(config *config.Config, registry *incident.Registry, silences *silence.Registry) (*Notifier, error) {
+	n := &Notifier{
+		registry: registry,
+		dispatcher: &dispatcher{
+			escalation: escalation.NewEscalation(registry, config),
+			logger:     config.Logger.Named("dispatcher"),
+		},
+	}
+
+	channels, err := n.newChannels(config)
+	if err != nil {
+		return nil, err
+	}
+
+	silencer, err := silence.NewSilencer(n.dispatcher, silences, config)
+	if err != nil {
+		closeChannels(channels, nil)
+		return nil, fmt.Errorf("could not create silencer: %w", err)
+	}
+	n.silencer = silencer
+
+	n.setChannels(config, channels)
+	n.INotifier = incident.NewTracker(silencer, registry, incident.NewLinks(config))
+
+	return n, nil
+}
+
+// Reload applies the channels, escalation policies, routes and maintenance windows of the config.
+// The channels with unchanged settings are kept with their reminders, digest and rate limits,
+// and the incidents of the removed monitors are resolved. Nothing is changed if the config can not be applied.
+func (n *Notifier) Reload(config *config.Config) error {
+	channels, err := n.newChannels(config)
+	if err != nil {
+		return err
+	}
+
+	if err := n.silencer.Reload(config); err != nil {
+		closeChannels(channels, n.channels)
+		return fmt.Errorf("could not reload silencer: %w", err)
+	}
+
+	n.dispatcher.escalation.Reload(config)
+	n.setChannels(config, channels)
+
+	monitors := map[string]bool{}
+	for _, mon := range config.MonitoredServices.Http {
+		monitors[mon.Name] = true
+	}
+
+	for _, open := range n.registry.List() {
+		if !monitors[open.Endpoint] {
+			config.Logger.Info("Resolving incident of removed monitor", "endpoint", open.Endpoint, "incident", open.ID)
+			n.registry.Resolve(open.Endpoint)
+		}
+	}
+
+	return nil
+}
+
+// Close closes the channels and waits until the events sent before are delivered
+func (n *Notifier) Close() {
+	closeChannels(n.channels, nil)
+
+	for _, ch := range n.channels {
+		ch.wait()
+	}
+}
+
+// newChannels creates the channels of the notify_service, escalation policies and routes, reusing
+// the current channels whose settings did not change
+func (n *Notifier) newChannels(config *config.Config) (map[string]*channel, error) {
+	channels := map[string]*channel{}
+
+	targets := []routing.Target{{Key: config.NotifyService, Channel: config.NotifyService}}
+	for _, tiers := range config.EscalationPolicies {
+		for _, tier := range tiers {
+			for _, name := range tier.Notify {
+				targets = append(targets, routing.Target{Key: name, Channel: name})
+			}
+		}
+	}
+	targets = append(targets, routing.NewRouter(config).Targets()...)
+
+	for _, target := range targets {
+		if _, ok := channels[target.Key]; ok {
+			continue
+		}
+
+		instance, ok := config.Channels[target.Channel]
+		if !ok {
+			closeChannels(channels, n.channels)
+			return nil, fmt.Errorf("notification service %s is not defined", target.Channel)
+		}
+
+		settings := instance.NotificationServices
+		if len(target.To) > 0 {
+			// the route replaces the recipients of the instance
+			settings.Email.To = target.To
+			settings.Sms.To = target.To
+		}
+
+		spec, err := channelSpec(instance.Type, settings, config)
+		if err != nil {
+			closeChannels(channels, n.channels)
+			return nil, fmt.Errorf("could not create channel %s: %w", target.Key, err)
+		}
+
+		if current, ok := n.channels[target.Key]; ok && current.spec == spec {
+			channels[target.Key] = current
+			continue
+		}
+
+		ch, err := newChannel(target.Key, instance.Type, settings, n.registry, config)
+		if err != nil {
+			closeChannels(channels, n.channels)
+			return nil, fmt.Errorf("could not create channel %s: %w", target.Key, err)
+		}
+		ch.spec = spec
+
+		channels[target.Key] = ch
+	}
+
+	return channels, nil
+}
+
+// setChannels switches the dispatcher to the channels and routes of the config, closing the channels no longer used
+func (n *Notifier) setChannels(config *config.Config, channels map[string]*channel) {
+	notifiers := map[string]common.INotifier{}
+	for key, ch := range channels {
+		notifiers[key] = ch
+	}
+
+	for _, ch := range channels {
+		ch.reload(config)
+	}
+
+	n.dispatcher.set(notifiers, config.NotifyService, routing.NewRouter(config))
+
+	closeChannels(n.channels, channels)
+	n.channels = channels
+}
+
+// closeChannels closes the channels which are not kept
+func closeChannels(channels map[string]*channel, keep map[string]*channel) {
+	for key, ch := range channels {
+		if keep[key] != ch {
+			ch.close()
+		}
+	}
+}
+
+// channelSpec returns the settings a channel depends on, used to tell if it has to be replaced on reload.
+// The settings of the monitors are not part of it, so adding or changing a monitor keeps the state of the channels.
+func channelSpec(notifierType string, settings config.NotificationServices, conf *config.Config) (string, error) {
+	spec, err := json.Marshal(struct {
+		Type     string
+		Settings config.NotificationServices
+		Delivery config.Delivery
+		Policy   config.Policy
+	}{notifierType, settings, conf.Delivery, conf.Policy})
+	if err != nil {
+		return "", err
+	}
+
+	return string(spec), nil
+}
+
+// newChannel returns an instance of the notifier type with the provided settings, wrapped in the reminders, digest,
+// notification policy and delivery layer, the name identifies the channel in the logs and spool
+func newChannel(name string, notifierType string, settings config.NotificationServices, registry *incident.Registry, conf *config.Config) (*channel, error) {
+	notifierService, err := newNotifier(name, notifierType, settings, conf)
+	if err != nil {
+		return nil, err
+	}
+
+	deliveryService, err := delivery.NewDelivery(notifierService, name, conf)
+	if err != nil {
+		return nil, fmt.Errorf("could not create delivery instance: %w", err)
+	}
+
+	ch := &channel{close: deliveryService.Close, wait: deliveryService.Wait}
+
+	var notifier common.INotifier = policy.NewPolicy(deliveryService, name, conf)
+
+	if digestSettings := settings.Channel(notifierType).Digest; digestSettings.Window > 0 {
+		digestService := digest.NewDigest(notifier, name, digestSettings, conf)
+		notifier = digestService
+
+		// the collected endpoints are sent before the channel is replaced
+		ch.close = func() {
+			digestService.Flush()
+			deliveryService.Close()
+		}
+	}
+
+	reminderService := reminder.NewReminder(notifier, name, settings.Channel(notifierType), registry, conf)
+	ch.INotifier = reminderService
+
+	ch.reload = func(conf *config.Config) {
+		reminderService.Reload(conf)
+		deliveryService.Reload(conf)
+	}
+
+	return ch, nil
+}
+
+// newNotifier returns an instance of the notifier type with the provided settings
+func newNotifier(name string, notifierType string, settings config.NotificationServices, conf *config.Config) (common.INotifier, error) {
+	notifierFactory, ok := availableNotifiers[common.NotifierType(notifierType)]
 	if !ok {
 		return nil, errors.New("selected notifier not available")
 	}
 
-	notifierService, err := notifierFactory().WithConfig(config)
+	// the notifiers read their settings from the config, so every channel gets its own copy
+	channelConfig := *conf
+	channelConfig.Services = settings
+	channelConfig.Logger = conf.Logger.With("channel", name)
+
+	notifierService, err := notifierFactory().WithConfig(&channelConfig)
 	if err != nil {
 		return nil, fmt.Errorf("could not create notifier instance: %w", err)
 	}
 
 	return notifierService, nil
 }
+
+// SendTest sends a sample alert straight to the notification service, without the reminders, digest,
+// notification policy and retries, to check that its settings work
+func SendTest(config *config.Config, name string) error {
+	instance, ok := config.Channels[name]
+	if !ok {
+		return fmt.Errorf("notification service %s is not defined", name)
+	}
+
+	notifierService, err := newNotifier(name, instance.Type, instance.NotificationServices, config)
+	if err != nil {
+		return fmt.Errorf("could not create channel %s: %w", name, err)
+	}
+
+	event := common.NewEvent(common.KindAlert, []common.Endpoint{
+		{
+			Name:     "go-notify test",
+			Url:      "https://example.com",
+			Status:   common.StatusDown,
+			Severity: common.SeverityInfo,
+			Since:    time.Now(),
+			Error:    fmt.Sprintf("test notification sent to %s, no action is needed", name),
+		},
+	})
+
+	return notifierService.Send(event)
+}
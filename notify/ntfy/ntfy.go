@@ -0,0 +1,159 @@
+package ntfy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ZeljkoBenovic/go-notify/config"
+	"github.com/ZeljkoBenovic/go-notify/notify/common"
+	"github.com/hashicorp/go-hclog"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const requestTimeout = 30 * time.Second
+
+// ntfy message priorities
+const (
+	priorityDefault = 3
+	priorityHigh    = 4
+	priorityUrgent  = 5
+)
+
+type ntfy struct {
+	server   string
+	topic    string
+	token    string
+	username string
+	password string
+	tags     []string
+
+	client *http.Client
+	logger hclog.Logger
+}
+
+// message is the JSON body accepted by the ntfy publish endpoint
+type message struct {
+	Topic    string   `json:"topic"`
+	Title    string   `json:"title"`
+	Message  string   `json:"message"`
+	Priority int      `json:"priority"`
+	Tags     []string `json:"tags,omitempty"`
+	Click    string   `json:"click,omitempty"`
+}
+
+func (n *ntfy) WithConfig(config *config.Config) (common.INotifier, error) {
+	if config.Services.Ntfy.Server == "" {
+		return nil, errors.New("server for ntfy not defined")
+	}
+
+	if config.Services.Ntfy.Topic == "" {
+		return nil, errors.New("topic for ntfy not defined")
+	}
+
+	n.server = strings.TrimRight(config.Services.Ntfy.Server, "/")
+	n.topic = config.Services.Ntfy.Topic
+	n.token = config.Services.Ntfy.Token
+	n.username = config.Services.Ntfy.Username
+	n.password = config.Services.Ntfy.Password
+	n.tags = config.Services.Ntfy.Tags
+	n.logger = config.Logger.Named("ntfy")
+
+	n.logger.Debug("ntfy config successfully initialized")
+	return n, nil
+}
+
+func (n ntfy) Send(event common.Event) error {
+	n.logger.Debug("Send function")
+
+	severity := event.Severity()
+
+	body, err := json.Marshal(message{
+		Topic:    n.topic,
+		Title:    event.Title(),
+		Message:  event.Text(),
+		Priority: priority(severity),
+		Tags:     append([]string{tag(event.Kind, severity)}, n.tags...),
+		Click:    event.Url(),
+	})
+	if err != nil {
+		return fmt.Errorf("could not marshal ntfy message: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, n.server, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("could not create ntfy request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	switch {
+	case n.token != "":
+		req.Header.Set("Authorization", "Bearer "+n.token)
+	case n.username != "":
+		req.SetBasicAuth(n.username, n.password)
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("could not send ntfy message: %w", err)
+	}
+	defer func(Body io.ReadCloser) {
+		_ = Body.Close()
+	}(resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		respBody, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("ntfy server returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	n.logger.Info("ntfy notification successfully sent", "topic", n.topic)
+	return nil
+}
+
+func (n ntfy) SendMockup() error {
+	fmt.Println("Sending...")
+
+	return nil
+}
+
+func NotifierFactory() common.INotifier {
+	return &ntfy{
+		client: &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// priority maps the event severity to the ntfy message priority
+func priority(severity common.Severity) int {
+	switch severity {
+	case common.SeverityInfo:
+		return priorityDefault
+	case common.SeverityWarning:
+		return priorityHigh
+	default:
+		return priorityUrgent
+	}
+}
+
+// tag maps the event kind and severity to an ntfy emoji tag
+func tag(kind common.Kind, severity common.Severity) string {
+	switch kind {
+	case common.KindRecovery:
+		return "white_check_mark"
+	case common.KindDigest, common.KindSummary:
+		return "memo"
+	}
+
+	switch severity {
+	case common.SeverityInfo:
+		return "information_source"
+	case common.SeverityWarning:
+		return "warning"
+	default:
+		return "rotating_light"
+	}
+}
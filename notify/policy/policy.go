@@ -0,0 +1,182 @@
+package policy
+
+import (
+	"github.com/ZeljkoBenovic/go-notify/config"
+	"github.com/ZeljkoBenovic/go-notify/notify/common"
+	"github.com/hashicorp/go-hclog"
+	"sync"
+	"time"
+)
+
+// Policy sits between the monitor and a notification channel and decides which alerts are sent.
+// An endpoint is not notified again before the renotify interval passes, and the channel
+// will not send more than the rate limit of messages per rate window. The alerts held back
+// by the rate limit are reported with the next message, or in a summary when the window ends.
+type Policy struct {
+	notifier common.INotifier
+
+	renotifyInterval time.Duration
+	rateLimit        uint64
+	rateWindow       time.Duration
+
+	logger hclog.Logger
+
+	mux          sync.Mutex
+	lastNotified map[string]time.Time
+	windowStart  time.Time
+	sent         uint64
+	suppressed   uint64
+	summary      *time.Timer
+}
+
+// NewPolicy wraps the notifier of the provided channel with the notification policy
+func NewPolicy(notifier common.INotifier, channel string, config *config.Config) *Policy {
+	return &Policy{
+		notifier:         notifier,
+		renotifyInterval: time.Duration(config.Policy.RenotifyInterval),
+		rateLimit:        config.Policy.RateLimit,
+		rateWindow:       time.Duration(config.Policy.RateWindow),
+		logger:           config.Logger.Named("policy").With("channel", channel),
+		lastNotified:     map[string]time.Time{},
+	}
+}
+
+// Send forwards the event to the notifier if the policy allows it
+func (p *Policy) Send(event common.Event) error {
+	p.mux.Lock()
+
+	now := time.Now()
+
+	switch event.Kind {
+	case common.KindAlert:
+		event.Endpoints = p.dedup(event.Endpoints, now)
+	case common.KindRecovery, common.KindDigest:
+		p.forgetRecovered(event.Endpoints)
+	}
+
+	if len(event.Affected()) == 0 {
+		p.mux.Unlock()
+		p.logger.Debug("all failing endpoints were notified recently, skipping", "event", event.ID)
+		return nil
+	}
+
+	if !p.allow(now) {
+		p.suppressed++
+		suppressed := p.suppressed
+		p.scheduleSummary(now)
+		p.mux.Unlock()
+
+		p.logger.Info("rate limit reached, alert suppressed", "event", event.ID, "suppressed", suppressed)
+		return nil
+	}
+
+	if event.Kind == common.KindAlert {
+		for _, ep := range event.Failing() {
+			p.lastNotified[ep.Name] = now
+		}
+	}
+
+	event.Suppressed = p.suppressed
+	p.suppressed = 0
+
+	p.mux.Unlock()
+
+	return p.notifier.Send(event)
+}
+
+func (p *Policy) SendMockup() error {
+	return p.notifier.SendMockup()
+}
+
+func (p *Policy) WithConfig(config *config.Config) (common.INotifier, error) {
+	notifier, err := p.notifier.WithConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	p.notifier = notifier
+
+	return p, nil
+}
+
+// dedup drops the failing endpoints that were notified within the renotify interval
+func (p *Policy) dedup(endpoints []common.Endpoint, now time.Time) []common.Endpoint {
+	if p.renotifyInterval == 0 {
+		return endpoints
+	}
+
+	filtered := make([]common.Endpoint, 0, len(endpoints))
+	for _, ep := range endpoints {
+		if ep.Status != common.StatusUp {
+			if last, ok := p.lastNotified[ep.Name]; ok && now.Sub(last) < p.renotifyInterval {
+				p.logger.Debug("endpoint notified recently", "endpoint", ep.Name, "last", last)
+				continue
+			}
+		}
+
+		filtered = append(filtered, ep)
+	}
+
+	return filtered
+}
+
+// forgetRecovered drops the last notification of the recovered endpoints, so their next outage is notified right away
+func (p *Policy) forgetRecovered(endpoints []common.Endpoint) {
+	for _, ep := range endpoints {
+		if ep.Status == common.StatusUp {
+			delete(p.lastNotified, ep.Name)
+		}
+	}
+}
+
+// allow counts the message against the rate limit, returning false if the limit is reached
+func (p *Policy) allow(now time.Time) bool {
+	if p.rateLimit == 0 {
+		return true
+	}
+
+	if now.Sub(p.windowStart) >= p.rateWindow {
+		p.windowStart = now
+		p.sent = 0
+	}
+
+	if p.sent >= p.rateLimit {
+		return false
+	}
+
+	p.sent++
+
+	return true
+}
+
+// scheduleSummary sends the number of suppressed alerts when the current rate window ends
+func (p *Policy) scheduleSummary(now time.Time) {
+	if p.summary != nil {
+		return
+	}
+
+	p.summary = time.AfterFunc(p.windowStart.Add(p.rateWindow).Sub(now), p.sendSummary)
+}
+
+// sendSummary sends the summary of suppressed alerts, if they were not already reported with another message
+func (p *Policy) sendSummary() {
+	p.mux.Lock()
+
+	p.summary = nil
+
+	if p.suppressed == 0 || !p.allow(time.Now()) {
+		p.mux.Unlock()
+		return
+	}
+
+	event := common.NewEvent(common.KindSummary, nil)
+	event.Suppressed = p.suppressed
+	p.suppressed = 0
+
+	p.mux.Unlock()
+
+	p.logger.Info("sending suppressed alerts summary", "suppressed", event.Suppressed)
+
+	if err := p.notifier.Send(event); err != nil {
+		p.logger.Error("could not send suppressed alerts summary", "error", err.Error())
+	}
+}
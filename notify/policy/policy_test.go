@@ -0,0 +1,164 @@
+package policy
+
+import (
+	"github.com/ZeljkoBenovic/go-notify/config"
+	"github.com/ZeljkoBenovic/go-notify/notify/common"
+	"github.com/hashicorp/go-hclog"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recorder is a notifier keeping the events it was sent
+type recorder struct {
+	mux    sync.Mutex
+	events []common.Event
+}
+
+func (r *recorder) Send(event common.Event) error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	r.events = append(r.events, event)
+	return nil
+}
+
+func (r *recorder) SendMockup() error {
+	return nil
+}
+
+func (r *recorder) WithConfig(*config.Config) (common.INotifier, error) {
+	return r, nil
+}
+
+func (r *recorder) sent() []common.Event {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	return append([]common.Event(nil), r.events...)
+}
+
+func event(kind common.Kind, statuses map[string]common.Status) common.Event {
+	var endpoints []common.Endpoint
+	for name, status := range statuses {
+		endpoints = append(endpoints, common.Endpoint{Name: name, Url: "http://" + name, Status: status})
+	}
+
+	return common.NewEvent(kind, endpoints)
+}
+
+func newTestPolicy(notifier common.INotifier, settings config.Policy) *Policy {
+	return NewPolicy(notifier, "test", &config.Config{Logger: hclog.NewNullLogger(), Policy: settings})
+}
+
+func TestPolicyRenotify(t *testing.T) {
+	down := map[string]common.Status{"api": common.StatusDown}
+	up := map[string]common.Status{"api": common.StatusUp}
+
+	tests := []struct {
+		name   string
+		events []common.Event
+		want   []bool
+	}{
+		{
+			name:   "repeated alert",
+			events: []common.Event{event(common.KindAlert, down), event(common.KindAlert, down)},
+			want:   []bool{true, false},
+		},
+		{
+			name:   "down, up, then down",
+			events: []common.Event{event(common.KindAlert, down), event(common.KindRecovery, up), event(common.KindAlert, down)},
+			want:   []bool{true, true, true},
+		},
+		{
+			name:   "down, recovered in a digest, then down",
+			events: []common.Event{event(common.KindAlert, down), event(common.KindDigest, up), event(common.KindAlert, down)},
+			want:   []bool{true, true, true},
+		},
+		{
+			name: "other endpoints are still deduplicated",
+			events: []common.Event{
+				event(common.KindAlert, map[string]common.Status{"api": common.StatusDown, "db": common.StatusDown}),
+				event(common.KindRecovery, up),
+				event(common.KindAlert, map[string]common.Status{"db": common.StatusDown}),
+			},
+			want: []bool{true, true, false},
+		},
+		{
+			name:   "reminders are not deduplicated",
+			events: []common.Event{event(common.KindAlert, down), event(common.KindReminder, down)},
+			want:   []bool{true, true},
+		},
+	}
+
+	for _, tt := range tests {
+		notifier := &recorder{}
+		p := newTestPolicy(notifier, config.Policy{RenotifyInterval: config.Duration(time.Hour)})
+
+		for i, e := range tt.events {
+			before := len(notifier.sent())
+			if err := p.Send(e); err != nil {
+				t.Fatalf("%s: Send error = %v", tt.name, err)
+			}
+
+			if sent := len(notifier.sent()) > before; sent != tt.want[i] {
+				t.Errorf("%s: event %d (%s) sent = %v, want %v", tt.name, i, e.Kind, sent, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestPolicyRateLimit(t *testing.T) {
+	tests := []struct {
+		name   string
+		limit  uint64
+		alerts int
+		// wantSent are the alerts sent in the rate window, wantSuppressed is the count of the summary after it
+		wantSent       int
+		wantSuppressed uint64
+	}{
+		{name: "no limit", alerts: 5, wantSent: 5},
+		{name: "below the limit", limit: 5, alerts: 3, wantSent: 3},
+		{name: "at the limit", limit: 3, alerts: 3, wantSent: 3},
+		{name: "over the limit", limit: 2, alerts: 5, wantSent: 2, wantSuppressed: 3},
+	}
+
+	const window = 50 * time.Millisecond
+
+	for _, tt := range tests {
+		notifier := &recorder{}
+		p := newTestPolicy(notifier, config.Policy{RateLimit: tt.limit, RateWindow: config.Duration(window)})
+
+		// every alert is about another endpoint, so none of them is deduplicated
+		for i := 0; i < tt.alerts; i++ {
+			name := string(rune('a' + i))
+			if err := p.Send(event(common.KindAlert, map[string]common.Status{name: common.StatusDown})); err != nil {
+				t.Fatalf("%s: Send error = %v", tt.name, err)
+			}
+		}
+
+		if sent := len(notifier.sent()); sent != tt.wantSent {
+			t.Errorf("%s: sent %d alerts, want %d", tt.name, sent, tt.wantSent)
+		}
+
+		time.Sleep(2 * window)
+
+		var suppressed uint64
+		for _, e := range notifier.sent() {
+			if e.Kind == common.KindSummary {
+				suppressed += e.Suppressed
+			}
+		}
+		if suppressed != tt.wantSuppressed {
+			t.Errorf("%s: summary of %d suppressed alerts, want %d", tt.name, suppressed, tt.wantSuppressed)
+		}
+
+		// the next message starts a new window, and does not report the suppressed alerts again
+		if err := p.Send(event(common.KindAlert, map[string]common.Status{"next": common.StatusDown})); err != nil {
+			t.Fatalf("%s: Send error = %v", tt.name, err)
+		}
+		if sent := notifier.sent(); sent[len(sent)-1].Suppressed != 0 {
+			t.Errorf("%s: next alert reports %d suppressed alerts, want none", tt.name, sent[len(sent)-1].Suppressed)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package reminder
+
+import (
+	"fmt"
+	"github.com/ZeljkoBenovic/go-notify/config"
+	"github.com/ZeljkoBenovic/go-notify/notify/common"
+	"github.com/ZeljkoBenovic/go-notify/notify/incident"
+	"github.com/hashicorp/go-hclog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Reminder turns the repeated alerts of an endpoint that stays down into a "still down" reminder,
+// sent once per repeat interval of the monitor, or of the channel if the monitor does not set one.
+// Reminders stop once the endpoint recovers, and nothing is sent about an acknowledged incident
+// until its recovery. If no repeat interval is set, the other alerts are passed on unchanged.
+type Reminder struct {
+	notifier common.INotifier
+	registry *incident.Registry
+
+	repeatEvery time.Duration
+	monitors    map[string]time.Duration
+
+	logger hclog.Logger
+
+	mux      sync.Mutex
+	lastSent map[string]time.Time
+}
+
+// NewReminder wraps the notifier of the provided channel with the reminders, using the repeat interval of the channel settings
+func NewReminder(notifier common.INotifier, channel string, settings config.Channel, registry *incident.Registry, config *config.Config) *Reminder {
+	r := &Reminder{
+		notifier:    notifier,
+		registry:    registry,
+		repeatEvery: time.Duration(settings.RepeatEvery),
+		logger:      config.Logger.Named("reminder").With("channel", channel),
+		lastSent:    map[string]time.Time{},
+	}
+
+	r.Reload(config)
+
+	return r
+}
+
+// Reload replaces the repeat intervals of the monitors with the ones of the config,
+// the reminders already sent are kept
+func (r *Reminder) Reload(config *config.Config) {
+	monitors := map[string]time.Duration{}
+	for _, mon := range config.MonitoredServices.Http {
+		if mon.RepeatEvery > 0 {
+			monitors[mon.Name] = time.Duration(mon.RepeatEvery)
+		}
+	}
+
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	r.monitors = monitors
+}
+
+// Send forwards the first alert of every endpoint, and the reminders that are due
+func (r *Reminder) Send(event common.Event) error {
+	switch event.Kind {
+	case common.KindAlert:
+	case common.KindRecovery:
+		r.mux.Lock()
+		for _, ep := range event.Endpoints {
+			delete(r.lastSent, ep.Name)
+		}
+		r.mux.Unlock()
+
+		return r.notifier.Send(event)
+	default:
+		return r.notifier.Send(event)
+	}
+
+	now := time.Now()
+
+	var alerts, reminders []common.Endpoint
+
+	r.mux.Lock()
+
+	for _, ep := range event.Endpoints {
+		if ep.Status == common.StatusUp {
+			alerts = append(alerts, ep)
+			continue
+		}
+
+		last, seen := r.lastSent[ep.Name]
+		repeatEvery := r.repeatInterval(ep.Name)
+
+		switch {
+		case r.acknowledged(ep.Name):
+			r.logger.Debug("incident acknowledged, no alert sent", "endpoint", ep.Name)
+		case !seen || repeatEvery == 0:
+			r.lastSent[ep.Name] = now
+			alerts = append(alerts, ep)
+		case now.Sub(last) >= repeatEvery:
+			r.lastSent[ep.Name] = now
+			reminders = append(reminders, ep)
+		}
+	}
+
+	r.mux.Unlock()
+
+	var errs []string
+
+	event.Endpoints = alerts
+	if len(event.Failing()) > 0 {
+		if err := r.notifier.Send(event); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if len(reminders) > 0 {
+		reminder := common.NewEvent(common.KindReminder, reminders)
+
+		r.logger.Info("sending reminder", "event", reminder.ID, "endpoints", len(reminders))
+
+		if err := r.notifier.Send(reminder); err != nil {
+			errs = append(errs, fmt.Sprintf("could not send reminder: %s", err.Error()))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+func (r *Reminder) SendMockup() error {
+	return r.notifier.SendMockup()
+}
+
+func (r *Reminder) WithConfig(config *config.Config) (common.INotifier, error) {
+	notifier, err := r.notifier.WithConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	r.notifier = notifier
+
+	return r, nil
+}
+
+// repeatInterval returns the repeat interval of the monitor, falling back to the one of the channel
+func (r *Reminder) repeatInterval(endpoint string) time.Duration {
+	if repeatEvery, ok := r.monitors[endpoint]; ok {
+		return repeatEvery
+	}
+
+	return r.repeatEvery
+}
+
+// acknowledged returns true if the open incident of the endpoint was acknowledged
+func (r *Reminder) acknowledged(endpoint string) bool {
+	open, ok := r.registry.Get(endpoint)
+
+	return ok && open.Acknowledged()
+}
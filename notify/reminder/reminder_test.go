@@ -0,0 +1,129 @@
+package reminder
+
+import (
+	"github.com/ZeljkoBenovic/go-notify/config"
+	"github.com/ZeljkoBenovic/go-notify/notify/common"
+	"github.com/ZeljkoBenovic/go-notify/notify/incident"
+	"github.com/hashicorp/go-hclog"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recorder is a notifier keeping the events it was sent
+type recorder struct {
+	mux    sync.Mutex
+	events []common.Event
+}
+
+func (r *recorder) Send(event common.Event) error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	r.events = append(r.events, event)
+	return nil
+}
+
+func (r *recorder) SendMockup() error {
+	return nil
+}
+
+func (r *recorder) WithConfig(*config.Config) (common.INotifier, error) {
+	return r, nil
+}
+
+func (r *recorder) sent() []common.Event {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	return append([]common.Event(nil), r.events...)
+}
+
+func TestReminder(t *testing.T) {
+	down := common.Endpoint{Name: "api", Url: "http://api", Status: common.StatusDown}
+	up := common.Endpoint{Name: "api", Url: "http://api", Status: common.StatusUp}
+
+	alert := common.NewEvent(common.KindAlert, []common.Endpoint{down})
+	recovery := common.NewEvent(common.KindRecovery, []common.Endpoint{up})
+
+	tests := []struct {
+		name string
+		// channel and monitor are the repeat intervals of the channel and the monitor
+		channel      time.Duration
+		monitor      time.Duration
+		acknowledged bool
+		events       []common.Event
+		want         []common.Kind
+	}{
+		{
+			name:   "no repeat interval",
+			events: []common.Event{alert, alert},
+			want:   []common.Kind{common.KindAlert, common.KindAlert},
+		},
+		{
+			name:    "reminder not due",
+			channel: time.Hour,
+			events:  []common.Event{alert, alert},
+			want:    []common.Kind{common.KindAlert},
+		},
+		{
+			name:    "reminder due",
+			channel: time.Nanosecond,
+			events:  []common.Event{alert, alert},
+			want:    []common.Kind{common.KindAlert, common.KindReminder},
+		},
+		{
+			name:    "repeat interval of the monitor",
+			channel: time.Hour,
+			monitor: time.Nanosecond,
+			events:  []common.Event{alert, alert},
+			want:    []common.Kind{common.KindAlert, common.KindReminder},
+		},
+		{
+			name:    "recovery stops the reminders",
+			channel: time.Hour,
+			events:  []common.Event{alert, recovery, alert},
+			want:    []common.Kind{common.KindAlert, common.KindRecovery, common.KindAlert},
+		},
+		{
+			name:         "acknowledged incident",
+			channel:      time.Nanosecond,
+			acknowledged: true,
+			events:       []common.Event{alert, alert, recovery},
+			want:         []common.Kind{common.KindRecovery},
+		},
+	}
+
+	for _, tt := range tests {
+		conf := &config.Config{Logger: hclog.NewNullLogger()}
+		conf.MonitoredServices.Http = []config.Monitor{{Name: "api", RepeatEvery: config.Duration(tt.monitor)}}
+
+		registry := incident.NewRegistry()
+		if tt.acknowledged {
+			open := registry.Open(down)
+			if _, err := registry.Acknowledge(open.ID, "me"); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		notifier := &recorder{}
+		r := NewReminder(notifier, "test", config.Channel{RepeatEvery: config.Duration(tt.channel)}, registry, conf)
+
+		for _, e := range tt.events {
+			// the clock moves on between the events, so a reminder every nanosecond is due
+			time.Sleep(time.Millisecond)
+			if err := r.Send(e); err != nil {
+				t.Fatalf("%s: Send error = %v", tt.name, err)
+			}
+		}
+
+		var kinds []common.Kind
+		for _, e := range notifier.sent() {
+			kinds = append(kinds, e.Kind)
+		}
+		if !reflect.DeepEqual(kinds, tt.want) {
+			t.Errorf("%s: sent %v, want %v", tt.name, kinds, tt.want)
+		}
+	}
+}
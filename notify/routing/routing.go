@@ -0,0 +1,94 @@
+package routing
+
+import (
+	"github.com/ZeljkoBenovic/go-notify/config"
+	"github.com/ZeljkoBenovic/go-notify/notify/common"
+	"github.com/ZeljkoBenovic/go-notify/notify/match"
+)
+
+// Target is a channel a route sends its events to
+type Target struct {
+	// Key identifies the channel instance, it is the channel name, with the route name appended
+	// if the route sets its own recipients
+	Key     string
+	Channel string
+	To      []string
+}
+
+type route struct {
+	matcher *match.Matcher
+	targets []Target
+	// next keeps checking the routes after this one matched
+	next bool
+}
+
+// Router picks the channels of an endpoint from the ordered routing table, using the labels of its monitor
+type Router struct {
+	routes []route
+	labels map[string]map[string]string
+}
+
+func NewRouter(config *config.Config) *Router {
+	r := &Router{
+		labels: map[string]map[string]string{},
+	}
+
+	for _, conf := range config.Routes {
+		rt := route{
+			matcher: match.New(conf.Match),
+			next:    conf.Continue,
+		}
+
+		for _, channel := range conf.Channels {
+			target := Target{Key: channel, Channel: channel}
+			if len(conf.To) > 0 {
+				target.Key = channel + "@" + conf.Name
+				target.To = conf.To
+			}
+			rt.targets = append(rt.targets, target)
+		}
+
+		r.routes = append(r.routes, rt)
+	}
+
+	for _, mon := range config.MonitoredServices.Http {
+		r.labels[mon.Name] = mon.Labels
+	}
+
+	return r
+}
+
+// Channels returns the keys of the channel instances of the endpoint, or nil if no route matches
+func (r *Router) Channels(ep common.Endpoint) []string {
+	var keys []string
+	seen := map[string]bool{}
+
+	for _, rt := range r.routes {
+		if !rt.matcher.Matches(ep.Name, ep.Url, r.labels[ep.Name]) {
+			continue
+		}
+
+		for _, target := range rt.targets {
+			if !seen[target.Key] {
+				seen[target.Key] = true
+				keys = append(keys, target.Key)
+			}
+		}
+
+		if !rt.next {
+			break
+		}
+	}
+
+	return keys
+}
+
+// Targets returns the channel instances of all routes
+func (r *Router) Targets() []Target {
+	var targets []Target
+	for _, rt := range r.routes {
+		targets = append(targets, rt.targets...)
+	}
+
+	return targets
+}
@@ -0,0 +1,61 @@
+package routing
+
+import (
+	"github.com/ZeljkoBenovic/go-notify/config"
+	"github.com/ZeljkoBenovic/go-notify/notify/common"
+	"reflect"
+	"testing"
+)
+
+func newTestRouter() *Router {
+	conf := &config.Config{
+		Routes: []config.Route{
+			{Name: "databases", Match: config.Matcher{Names: []string{"db-*"}}, Channels: []string{"dba"}},
+			{Name: "web", Match: config.Matcher{Labels: map[string]string{"team": "web"}}, Channels: []string{"web", "ops"}, Continue: true},
+			{Name: "internal", Match: config.Matcher{Url: "*.internal/*"}, Channels: []string{"ops"}, Continue: true},
+			{Name: "oncall", Match: config.Matcher{Labels: map[string]string{"tier": "1"}}, Channels: []string{"email"}, To: []string{"oncall@example.com"}},
+		},
+	}
+	conf.MonitoredServices.Http = []config.Monitor{
+		{Name: "shop", Labels: map[string]string{"team": "web", "tier": "1"}},
+		{Name: "blog", Labels: map[string]string{"team": "web"}},
+		{Name: "db-main", Labels: map[string]string{"team": "web"}},
+	}
+
+	return NewRouter(conf)
+}
+
+func TestRouterChannels(t *testing.T) {
+	tests := []struct {
+		name string
+		ep   common.Endpoint
+		want []string
+	}{
+		{name: "first route ends the routing", ep: common.Endpoint{Name: "db-main", Url: "http://db.internal/"}, want: []string{"dba"}},
+		{name: "continued routes", ep: common.Endpoint{Name: "shop", Url: "http://shop.example.com/"}, want: []string{"web", "ops", "email@oncall"}},
+		{name: "channels without duplicates", ep: common.Endpoint{Name: "blog", Url: "http://blog.internal/health"}, want: []string{"web", "ops"}},
+		{name: "no labels", ep: common.Endpoint{Name: "cache", Url: "http://cache.internal/"}, want: []string{"ops"}},
+		{name: "no route", ep: common.Endpoint{Name: "cache", Url: "http://cache.example.com/"}, want: nil},
+	}
+
+	r := newTestRouter()
+	for _, tt := range tests {
+		if got := r.Channels(tt.ep); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Channels = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRouterTargets(t *testing.T) {
+	want := []Target{
+		{Key: "dba", Channel: "dba"},
+		{Key: "web", Channel: "web"},
+		{Key: "ops", Channel: "ops"},
+		{Key: "ops", Channel: "ops"},
+		{Key: "email@oncall", Channel: "email", To: []string{"oncall@example.com"}},
+	}
+
+	if got := newTestRouter().Targets(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Targets = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,127 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cron is a standard five field cron expression: minute, hour, day of month, month and day of week
+type Cron struct {
+	minute, hour, dom, month, dow map[int]bool
+	// the day matches if either day field matches, unless one of them is a wildcard
+	domAny, dowAny bool
+}
+
+// cronFields holds the allowed range of every cron field
+var cronFields = [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+
+// ParseCron parses a five field cron expression supporting *, lists, ranges and steps
+func ParseCron(expr string) (*Cron, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields", expr)
+	}
+
+	sets := make([]map[int]bool, 5)
+	for i, field := range fields {
+		set, err := parseCronField(field, cronFields[i][0], cronFields[i][1])
+		if err != nil {
+			return nil, fmt.Errorf("could not parse cron expression %q: %w", expr, err)
+		}
+		sets[i] = set
+	}
+
+	// sunday can be written as 0 or 7
+	if sets[4][7] {
+		sets[4][0] = true
+	}
+
+	return &Cron{
+		minute: sets[0],
+		hour:   sets[1],
+		dom:    sets[2],
+		month:  sets[3],
+		dow:    sets[4],
+		domAny: fields[2] == "*",
+		dowAny: fields[4] == "*",
+	}, nil
+}
+
+func parseCronField(field string, min, max int) (map[int]bool, error) {
+	set := map[int]bool{}
+
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step <= 0 {
+				return nil, fmt.Errorf("invalid step in %q", part)
+			}
+			part = part[:i]
+		}
+
+		from, to := min, max
+		switch {
+		case part == "*":
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			var err1, err2 error
+			from, err1 = strconv.Atoi(bounds[0])
+			to, err2 = strconv.Atoi(bounds[1])
+			if err1 != nil || err2 != nil {
+				return nil, fmt.Errorf("invalid range %q", part)
+			}
+		default:
+			value, err := strconv.Atoi(part)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value %q", part)
+			}
+			from, to = value, value
+			// a single value with a step runs until the end of the range
+			if step > 1 {
+				to = max
+			}
+		}
+
+		if from < min || to > max || from > to {
+			return nil, fmt.Errorf("%q is out of range %d-%d", part, min, max)
+		}
+
+		for v := from; v <= to; v += step {
+			set[v] = true
+		}
+	}
+
+	return set, nil
+}
+
+// Matches returns true if the schedule fires in the minute of the provided time
+func (c *Cron) Matches(t time.Time) bool {
+	if !c.minute[t.Minute()] || !c.hour[t.Hour()] || !c.month[int(t.Month())] {
+		return false
+	}
+
+	dom, dow := c.dom[t.Day()], c.dow[int(t.Weekday())]
+	switch {
+	case c.domAny:
+		return dow
+	case c.dowAny:
+		return dom
+	default:
+		return dom || dow
+	}
+}
+
+// ActiveAt returns true if the schedule fired within the duration before the provided time
+func (c *Cron) ActiveAt(t time.Time, duration time.Duration) bool {
+	t = t.Truncate(time.Minute)
+	for start := t; t.Sub(start) < duration; start = start.Add(-time.Minute) {
+		if c.Matches(start) {
+			return true
+		}
+	}
+
+	return false
+}
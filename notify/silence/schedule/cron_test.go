@@ -0,0 +1,117 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCron(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantErr bool
+	}{
+		{expr: "* * * * *"},
+		{expr: "0 2 * * 1-5"},
+		{expr: "*/15 0-6/2 1,15 * 0,7"},
+		{expr: "5/10 * * * *"},
+		{expr: "  30   4  *  *  *  "},
+		{expr: "", wantErr: true},
+		{expr: "* * * *", wantErr: true},
+		{expr: "* * * * * *", wantErr: true},
+		{expr: "60 * * * *", wantErr: true},
+		{expr: "* 24 * * *", wantErr: true},
+		{expr: "* * 0 * *", wantErr: true},
+		{expr: "* * * 13 *", wantErr: true},
+		{expr: "* * * * 8", wantErr: true},
+		{expr: "5-1 * * * *", wantErr: true},
+		{expr: "*/0 * * * *", wantErr: true},
+		{expr: "*/x * * * *", wantErr: true},
+		{expr: "a * * * *", wantErr: true},
+		{expr: "1-x * * * *", wantErr: true},
+		{expr: "1,,2 * * * *", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		_, err := ParseCron(tt.expr)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseCron(%q) error = %v, wantErr %v", tt.expr, err, tt.wantErr)
+		}
+	}
+}
+
+func TestCronMatches(t *testing.T) {
+	// 2024-01-01 is a monday
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, time.January, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		expr string
+		time time.Time
+		want bool
+	}{
+		{expr: "* * * * *", time: at(1, 12, 34), want: true},
+		{expr: "30 2 * * *", time: at(1, 2, 30), want: true},
+		{expr: "30 2 * * *", time: at(1, 2, 31), want: false},
+		{expr: "30 2 * * *", time: at(1, 3, 30), want: false},
+		{expr: "*/15 * * * *", time: at(1, 5, 45), want: true},
+		{expr: "*/15 * * * *", time: at(1, 5, 50), want: false},
+		{expr: "5/20 * * * *", time: at(1, 5, 25), want: true},
+		{expr: "5/20 * * * *", time: at(1, 5, 0), want: false},
+		{expr: "0 0-6/3 * * *", time: at(1, 6, 0), want: true},
+		{expr: "0 0-6/3 * * *", time: at(1, 9, 0), want: false},
+		{expr: "0 0 * * 1-5", time: at(5, 0, 0), want: true},
+		{expr: "0 0 * * 1-5", time: at(6, 0, 0), want: false},
+		// sunday can be written as 0 or 7
+		{expr: "0 0 * * 0", time: at(7, 0, 0), want: true},
+		{expr: "0 0 * * 7", time: at(7, 0, 0), want: true},
+		{expr: "0 0 1 * *", time: at(1, 0, 0), want: true},
+		{expr: "0 0 1 * *", time: at(2, 0, 0), want: false},
+		{expr: "0 0 * 2 *", time: at(1, 0, 0), want: false},
+		// the day matches if either day field matches
+		{expr: "0 0 15 * 0", time: at(15, 0, 0), want: true},
+		{expr: "0 0 15 * 0", time: at(14, 0, 0), want: true},
+		{expr: "0 0 15 * 0", time: at(13, 0, 0), want: false},
+	}
+
+	for _, tt := range tests {
+		cron, err := ParseCron(tt.expr)
+		if err != nil {
+			t.Fatalf("ParseCron(%q) error = %v", tt.expr, err)
+		}
+
+		if got := cron.Matches(tt.time); got != tt.want {
+			t.Errorf("ParseCron(%q).Matches(%v) = %v, want %v", tt.expr, tt.time, got, tt.want)
+		}
+	}
+}
+
+func TestCronActiveAt(t *testing.T) {
+	cron, err := ParseCron("0 2 * * *")
+	if err != nil {
+		t.Fatalf("parseCron error = %v", err)
+	}
+
+	at := func(day, hour, minute, second int) time.Time {
+		return time.Date(2024, time.January, day, hour, minute, second, 0, time.UTC)
+	}
+
+	tests := []struct {
+		time     time.Time
+		duration time.Duration
+		want     bool
+	}{
+		{time: at(1, 2, 0, 0), duration: time.Hour, want: true},
+		{time: at(1, 2, 59, 59), duration: time.Hour, want: true},
+		{time: at(1, 3, 0, 0), duration: time.Hour, want: false},
+		{time: at(1, 1, 59, 59), duration: time.Hour, want: false},
+		{time: at(2, 1, 0, 0), duration: 24 * time.Hour, want: true},
+		{time: at(1, 2, 0, 30), duration: 0, want: false},
+	}
+
+	for _, tt := range tests {
+		if got := cron.ActiveAt(tt.time, tt.duration); got != tt.want {
+			t.Errorf("activeAt(%v, %v) = %v, want %v", tt.time, tt.duration, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package schedule
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ParseTime parses a RFC 3339 time, or a "2006-01-02 15:04" time in the provided location
+func ParseTime(value string, location *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	return time.ParseInLocation("2006-01-02 15:04", value, location)
+}
+
+// ParseClock parses a "15:04" time of the day into minutes since midnight
+func ParseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, err
+	}
+
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// ParseWeekday parses the full or the three letter name of a weekday, in any case
+func ParseWeekday(day string) (time.Weekday, error) {
+	lower := strings.ToLower(day)
+	for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+		name := strings.ToLower(weekday.String())
+		if lower == name || lower == name[:3] {
+			return weekday, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown weekday %q", day)
+}
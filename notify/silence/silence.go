@@ -0,0 +1,122 @@
+package silence
+
+import (
+	"errors"
+	"github.com/ZeljkoBenovic/go-notify/config"
+	"github.com/ZeljkoBenovic/go-notify/notify/common"
+	"github.com/ZeljkoBenovic/go-notify/notify/match"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrNotFound is returned for silences that do not exist or already expired
+	ErrNotFound = errors.New("silence not found")
+
+	errEmptyMatcher = errors.New("matcher has no conditions")
+	errAlwaysActive = errors.New("from and to must differ, the window would always be active")
+)
+
+// Silence is an ad-hoc suppression of the notifications of the matching monitors
+type Silence struct {
+	ID        string         `json:"id"`
+	Match     config.Matcher `json:"match"`
+	StartsAt  time.Time      `json:"starts_at"`
+	EndsAt    time.Time      `json:"ends_at"`
+	CreatedBy string         `json:"created_by"`
+	Comment   string         `json:"comment,omitempty"`
+
+	matcher *match.Matcher
+}
+
+// Registry keeps the silences until they expire
+type Registry struct {
+	mux      sync.RWMutex
+	silences map[string]Silence
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		silences: map[string]Silence{},
+	}
+}
+
+// Add creates a silence for the matching monitors, starting now and lasting for the provided duration
+func (r *Registry) Add(conditions config.Matcher, duration time.Duration, by, comment string) (Silence, error) {
+	if conditions.Empty() {
+		return Silence{}, errEmptyMatcher
+	}
+
+	if duration <= 0 {
+		return Silence{}, errors.New("duration must be greater than zero")
+	}
+
+	now := time.Now()
+	silence := Silence{
+		ID:        common.NewID(),
+		Match:     conditions,
+		StartsAt:  now,
+		EndsAt:    now.Add(duration),
+		CreatedBy: by,
+		Comment:   comment,
+		matcher:   match.New(conditions),
+	}
+
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	r.silences[silence.ID] = silence
+
+	return silence, nil
+}
+
+// Remove expires the silence with the provided ID
+func (r *Registry) Remove(id string) error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if _, ok := r.silences[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.silences, id)
+
+	return nil
+}
+
+// List returns the active silences, the ones ending first are listed first
+func (r *Registry) List() []Silence {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	now := time.Now()
+	silences := make([]Silence, 0, len(r.silences))
+
+	for id, silence := range r.silences {
+		if !now.Before(silence.EndsAt) {
+			delete(r.silences, id)
+			continue
+		}
+		silences = append(silences, silence)
+	}
+
+	sort.Slice(silences, func(i, j int) bool {
+		return silences[i].EndsAt.Before(silences[j].EndsAt)
+	})
+
+	return silences
+}
+
+// matching returns the first active silence matching the monitor
+func (r *Registry) matching(name, url string, labels map[string]string, now time.Time) (Silence, bool) {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+
+	for _, silence := range r.silences {
+		if now.Before(silence.EndsAt) && silence.matcher.Matches(name, url, labels) {
+			return silence, true
+		}
+	}
+
+	return Silence{}, false
+}
@@ -0,0 +1,149 @@
+package silence
+
+import (
+	"fmt"
+	"github.com/ZeljkoBenovic/go-notify/config"
+	"github.com/ZeljkoBenovic/go-notify/notify/common"
+	"github.com/hashicorp/go-hclog"
+	"sync"
+	"time"
+)
+
+// Silencer drops the alerts of the endpoints in an active maintenance window or silence.
+// The checks still run and the incidents are still tracked, only the notifications are suppressed.
+// A recovery is only sent if an alert about the outage was sent before.
+type Silencer struct {
+	notifier common.INotifier
+	silences *Registry
+	windows  []*window
+	labels   map[string]map[string]string
+
+	logger hclog.Logger
+
+	mux      sync.Mutex
+	notified map[string]bool
+}
+
+// NewSilencer wraps the notifier with the maintenance windows of the config and the silences of the registry
+func NewSilencer(notifier common.INotifier, silences *Registry, config *config.Config) (*Silencer, error) {
+	s := &Silencer{
+		notifier: notifier,
+		silences: silences,
+		logger:   config.Logger.Named("silence"),
+		notified: map[string]bool{},
+	}
+
+	if err := s.Reload(config); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Reload replaces the maintenance windows and monitor labels with the ones of the config,
+// the endpoints already notified about are kept unless their monitor was removed
+func (s *Silencer) Reload(config *config.Config) error {
+	var windows []*window
+	for _, conf := range config.Maintenance {
+		w, err := newWindow(conf)
+		if err != nil {
+			return fmt.Errorf("invalid maintenance window %s: %w", conf.Name, err)
+		}
+		windows = append(windows, w)
+	}
+
+	labels := map[string]map[string]string{}
+	for _, mon := range config.MonitoredServices.Http {
+		labels[mon.Name] = mon.Labels
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.windows = windows
+	s.labels = labels
+
+	for name := range s.notified {
+		if _, ok := labels[name]; !ok {
+			delete(s.notified, name)
+		}
+	}
+
+	return nil
+}
+
+// Send forwards the event without the silenced endpoints
+func (s *Silencer) Send(event common.Event) error {
+	if event.Kind != common.KindAlert && event.Kind != common.KindRecovery {
+		return s.notifier.Send(event)
+	}
+
+	now := time.Now()
+	kept := map[string]bool{}
+
+	s.mux.Lock()
+
+	for _, ep := range event.Affected() {
+		switch {
+		case event.Kind == common.KindRecovery:
+			if s.notified[ep.Name] {
+				kept[ep.Name] = true
+			}
+			delete(s.notified, ep.Name)
+		case s.silenced(ep, now):
+		default:
+			s.notified[ep.Name] = true
+			kept[ep.Name] = true
+		}
+	}
+
+	s.mux.Unlock()
+
+	if len(kept) == 0 {
+		return nil
+	}
+
+	// keep the healthy endpoints of an alert, so the message still shows how many services are checked
+	endpoints := make([]common.Endpoint, 0, len(event.Endpoints))
+	for _, ep := range event.Endpoints {
+		if kept[ep.Name] || (event.Kind == common.KindAlert && ep.Status == common.StatusUp) {
+			endpoints = append(endpoints, ep)
+		}
+	}
+	event.Endpoints = endpoints
+
+	return s.notifier.Send(event)
+}
+
+func (s *Silencer) SendMockup() error {
+	return s.notifier.SendMockup()
+}
+
+func (s *Silencer) WithConfig(config *config.Config) (common.INotifier, error) {
+	notifier, err := s.notifier.WithConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	s.notifier = notifier
+
+	return s, nil
+}
+
+// silenced returns true if the endpoint is in an active maintenance window or silence
+func (s *Silencer) silenced(ep common.Endpoint, now time.Time) bool {
+	labels := s.labels[ep.Name]
+
+	for _, w := range s.windows {
+		if w.activeAt(now) && w.matcher.Matches(ep.Name, ep.Url, labels) {
+			s.logger.Debug("alert suppressed by maintenance window", "endpoint", ep.Name, "window", w.name)
+			return true
+		}
+	}
+
+	if silence, ok := s.silences.matching(ep.Name, ep.Url, labels, now); ok {
+		s.logger.Debug("alert suppressed by silence", "endpoint", ep.Name, "silence", silence.ID)
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,117 @@
+package silence
+
+import (
+	"fmt"
+	"github.com/ZeljkoBenovic/go-notify/config"
+	"github.com/ZeljkoBenovic/go-notify/notify/match"
+	"github.com/ZeljkoBenovic/go-notify/notify/silence/schedule"
+	"time"
+)
+
+// window is a parsed maintenance window
+type window struct {
+	name     string
+	matcher  *match.Matcher
+	location *time.Location
+
+	// one-time window
+	start, end time.Time
+
+	// cron window
+	cron     *schedule.Cron
+	duration time.Duration
+
+	// weekday window, from and to are minutes since midnight
+	weekdays map[time.Weekday]bool
+	from, to int
+}
+
+// newWindow parses the maintenance window from the config
+func newWindow(conf config.MaintenanceWindow) (*window, error) {
+	w := &window{
+		name:     conf.Name,
+		location: time.Local,
+	}
+
+	var err error
+
+	if conf.Timezone != "" {
+		if w.location, err = time.LoadLocation(conf.Timezone); err != nil {
+			return nil, fmt.Errorf("could not load time zone: %w", err)
+		}
+	}
+
+	if conf.Match.Empty() {
+		return nil, errEmptyMatcher
+	}
+	w.matcher = match.New(conf.Match)
+
+	switch {
+	case conf.Start != "":
+		if w.start, err = schedule.ParseTime(conf.Start, w.location); err != nil {
+			return nil, fmt.Errorf("could not parse start: %w", err)
+		}
+		if w.end, err = schedule.ParseTime(conf.End, w.location); err != nil {
+			return nil, fmt.Errorf("could not parse end: %w", err)
+		}
+		if !w.end.After(w.start) {
+			return nil, fmt.Errorf("end must be after start")
+		}
+	case conf.Cron != "":
+		if w.cron, err = schedule.ParseCron(conf.Cron); err != nil {
+			return nil, err
+		}
+		w.duration = time.Duration(conf.Duration)
+	default:
+		if w.from, err = schedule.ParseClock(conf.From); err != nil {
+			return nil, fmt.Errorf("could not parse from: %w", err)
+		}
+		if w.to, err = schedule.ParseClock(conf.To); err != nil {
+			return nil, fmt.Errorf("could not parse to: %w", err)
+		}
+		if w.from == w.to {
+			return nil, errAlwaysActive
+		}
+
+		w.weekdays = map[time.Weekday]bool{}
+		for _, day := range conf.Weekdays {
+			weekday, err := schedule.ParseWeekday(day)
+			if err != nil {
+				return nil, err
+			}
+			w.weekdays[weekday] = true
+		}
+	}
+
+	return w, nil
+}
+
+// activeAt returns true if the window is active at the provided time
+func (w *window) activeAt(t time.Time) bool {
+	t = t.In(w.location)
+
+	switch {
+	case w.cron != nil:
+		return w.cron.ActiveAt(t, w.duration)
+	case !w.start.IsZero():
+		return !t.Before(w.start) && t.Before(w.end)
+	}
+
+	minute := t.Hour()*60 + t.Minute()
+
+	if w.from < w.to {
+		return w.onDay(t.Weekday()) && minute >= w.from && minute < w.to
+	}
+
+	// the window runs over midnight, so the part after midnight belongs to the previous day
+	if minute >= w.from {
+		return w.onDay(t.Weekday())
+	}
+
+	return minute < w.to && w.onDay((t.Weekday()+6)%7)
+}
+
+// onDay returns true if the window is active on the weekday, all days are allowed if none are set
+func (w *window) onDay(day time.Weekday) bool {
+	return len(w.weekdays) == 0 || w.weekdays[day]
+}
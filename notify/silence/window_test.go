@@ -0,0 +1,46 @@
+package silence
+
+import (
+	"github.com/ZeljkoBenovic/go-notify/config"
+	"testing"
+	"time"
+)
+
+func TestWindowWeekdays(t *testing.T) {
+	tests := []struct {
+		day     string
+		want    time.Weekday
+		wantErr bool
+	}{
+		{day: "mon", want: time.Monday},
+		{day: "Monday", want: time.Monday},
+		{day: "SUN", want: time.Sunday},
+		{day: "sat", want: time.Saturday},
+		{day: "mo", wantErr: true},
+		{day: "", wantErr: true},
+		{day: "holiday", wantErr: true},
+		{day: "monxyz", wantErr: true},
+		{day: "mond", wantErr: true},
+		{day: "tues", wantErr: true},
+		// the kelvin sign is shorter once lowercased
+		{day: "\u212a", wantErr: true},
+		{day: "\u212a\u212a\u212a", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		w, err := newWindow(config.MaintenanceWindow{
+			Match:    config.Matcher{Names: []string{"api"}},
+			From:     "01:00",
+			To:       "02:00",
+			Weekdays: []string{tt.day},
+		})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("weekday %q error = %v, wantErr %v", tt.day, err, tt.wantErr)
+			continue
+		}
+
+		if err == nil && !w.weekdays[tt.want] {
+			t.Errorf("weekday %q = %v, want %v", tt.day, w.weekdays, tt.want)
+		}
+	}
+}
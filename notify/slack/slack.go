@@ -26,7 +26,7 @@ func (s slack) WithConfig(config *config.Config) (common.INotifier, error) {
 	return s, nil
 }
 
-func (s slack) Send(monitor interface{}) error {
+func (s slack) Send(event common.Event) error {
 	fmt.Println("sending to SLACK webhook ", s.webhook)
 
 	return nil
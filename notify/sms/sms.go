@@ -0,0 +1,256 @@
+package sms
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ZeljkoBenovic/go-notify/config"
+	"github.com/ZeljkoBenovic/go-notify/notify/common"
+	"github.com/hashicorp/go-hclog"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	requestTimeout   = 30 * time.Second
+	defaultMaxLength = 160
+	// minExcerptLength is the shortest error or url excerpt that is still worth including in the message
+	minExcerptLength = 12
+)
+
+type sms struct {
+	baseUrl    string
+	accountSid string
+	authToken  string
+	from       string
+	to         []string
+	maxLength  int
+
+	client *http.Client
+	logger hclog.Logger
+}
+
+// errorResponse is the error returned by the Twilio compatible API
+type errorResponse struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (s *sms) WithConfig(config *config.Config) (common.INotifier, error) {
+	if config.Services.Sms.AccountSid == "" || config.Services.Sms.AuthToken == "" {
+		return nil, errors.New("account sid and auth token for SMS not defined")
+	}
+
+	if config.Services.Sms.From == "" {
+		return nil, errors.New("from number for SMS not defined")
+	}
+
+	if len(config.Services.Sms.To) == 0 {
+		return nil, errors.New("missing SMS recipient numbers")
+	}
+
+	s.baseUrl = strings.TrimRight(config.Services.Sms.BaseUrl, "/")
+	s.accountSid = config.Services.Sms.AccountSid
+	s.authToken = config.Services.Sms.AuthToken
+	s.from = config.Services.Sms.From
+	s.to = config.Services.Sms.To
+	s.maxLength = config.Services.Sms.MaxLength
+	s.logger = config.Logger.Named("sms")
+
+	if s.maxLength <= 0 {
+		s.maxLength = defaultMaxLength
+	}
+
+	s.logger.Debug("sms config successfully initialized")
+	return s, nil
+}
+
+func (s sms) Send(event common.Event) error {
+	s.logger.Debug("Send function")
+
+	text := condense(event, s.maxLength)
+
+	// send to every recipient and report all of those that failed
+	var failed []string
+	for _, to := range s.to {
+		if err := s.sendTo(to, text); err != nil {
+			s.logger.Error("could not send sms", "to", to, "error", err.Error())
+			failed = append(failed, fmt.Sprintf("%s: %s", to, err.Error()))
+			continue
+		}
+
+		s.logger.Info("sms notification successfully sent", "to", to)
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("could not send sms to %d of %d recipients: %s", len(failed), len(s.to), strings.Join(failed, "; "))
+	}
+
+	return nil
+}
+
+func (s sms) SendMockup() error {
+	fmt.Println("Sending...")
+
+	return nil
+}
+
+func NotifierFactory() common.INotifier {
+	return &sms{
+		client: &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// sendTo sends a single message to the provided number
+func (s sms) sendTo(to, text string) error {
+	form := url.Values{}
+	form.Set("To", to)
+	form.Set("From", s.from)
+	form.Set("Body", text)
+
+	reqUrl := fmt.Sprintf("%s/2010-04-01/Accounts/%s/Messages.json", s.baseUrl, url.PathEscape(s.accountSid))
+
+	req, err := http.NewRequest(http.MethodPost, reqUrl, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("could not create request: %w", err)
+	}
+
+	req.SetBasicAuth(s.accountSid, s.authToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("could not send request: %w", err)
+	}
+	defer func(Body io.ReadCloser) {
+		_ = Body.Close()
+	}(resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		respBody, _ := ioutil.ReadAll(resp.Body)
+
+		apiErr := errorResponse{}
+		if err := json.Unmarshal(respBody, &apiErr); err == nil && apiErr.Message != "" {
+			return fmt.Errorf("api returned status %d: %d %s", resp.StatusCode, apiErr.Code, apiErr.Message)
+		}
+
+		return fmt.Errorf("api returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	return nil
+}
+
+// condense creates a short text of the event which is not longer than limit characters.
+// If the full text does not fit, the error is shortened first, then dropped,
+// and at last the list of endpoints is cut with the number of omitted endpoints appended.
+func condense(event common.Event, limit int) string {
+	affected := event.Affected()
+
+	var header string
+	switch event.Kind {
+	case common.KindRecovery:
+		header = fmt.Sprintf("GONOTIFY %d RECOVERED: ", len(affected))
+	case common.KindDigest:
+		header = fmt.Sprintf("GONOTIFY DIGEST %d/%d DOWN: ", len(event.Failing()), len(affected))
+	case common.KindSummary:
+		header = fmt.Sprintf("GONOTIFY %d ALERTS SUPPRESSED", event.Suppressed)
+	default:
+		header = fmt.Sprintf("GONOTIFY %d/%d DOWN: ", len(affected), len(event.Endpoints))
+	}
+
+	if event.Suppressed > 0 && event.Kind != common.KindSummary {
+		header = strings.Replace(header, "GONOTIFY ", fmt.Sprintf("GONOTIFY +%d SUPPRESSED, ", event.Suppressed), 1)
+	}
+
+	hosts := make([]string, 0, len(affected))
+	for _, ep := range affected {
+		host := shortUrl(ep.Url)
+		if len(ep.Unreachable) > 0 {
+			host += fmt.Sprintf(" (+%d unreachable)", len(ep.Unreachable))
+		}
+		hosts = append(hosts, host)
+	}
+
+	text := header + strings.Join(hosts, ", ")
+
+	errText := ""
+	if len(affected) > 0 && affected[0].Error != "" {
+		errText = " | " + affected[0].Error
+	}
+
+	// everything fits
+	if length(text+errText) <= limit {
+		return text + errText
+	}
+
+	// shorten the error if the endpoint list fits
+	if length(text) <= limit {
+		if room := limit - length(text); errText != "" && room >= minExcerptLength {
+			return text + truncate(errText, room)
+		}
+
+		return text
+	}
+
+	// list as many endpoints as possible and add the number of the ones left out
+	text = header
+	for i, host := range hosts {
+		separator := ""
+		if i > 0 {
+			separator = ", "
+		}
+
+		more := fmt.Sprintf(" +%d more", len(hosts)-i-1)
+		if i == len(hosts)-1 {
+			more = ""
+		}
+
+		if length(text+separator+host+more) > limit {
+			// always show at least part of the first endpoint
+			if room := limit - length(text+more); i == 0 && room >= minExcerptLength {
+				text += truncate(host, room) + more
+				break
+			}
+
+			text = strings.TrimRight(text, " ") + fmt.Sprintf(" +%d more", len(hosts)-i)
+			break
+		}
+
+		text += separator + host
+	}
+
+	return truncate(text, limit)
+}
+
+// shortUrl strips the scheme and trailing slash to save characters
+func shortUrl(endpoint string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return endpoint
+	}
+
+	return strings.TrimRight(u.Host+u.Path, "/")
+}
+
+// length returns the number of characters in the string
+func length(s string) int {
+	return len([]rune(s))
+}
+
+// truncate cuts the string to limit characters, marking the cut with an ellipsis
+func truncate(s string, limit int) string {
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+
+	if limit <= 3 {
+		return string(runes[:limit])
+	}
+
+	return string(runes[:limit-3]) + "..."
+}
@@ -0,0 +1,293 @@
+package syslog
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"github.com/ZeljkoBenovic/go-notify/config"
+	"github.com/ZeljkoBenovic/go-notify/notify/common"
+	"github.com/hashicorp/go-hclog"
+	"io/ioutil"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	dialTimeout = 30 * time.Second
+	// sdID is the structured-data ID, 32473 is the private enterprise number reserved for documentation
+	sdID = "gonotify@32473"
+	// nilValue is used for the header fields that are not known
+	nilValue = "-"
+	// timestampFormat is RFC 3339 limited to the microsecond precision allowed by RFC 5424
+	timestampFormat = "2006-01-02T15:04:05.000000Z07:00"
+)
+
+// syslog severity levels
+const (
+	severityCritical = 2
+	severityWarning  = 4
+	severityNotice   = 5
+	severityInfo     = 6
+)
+
+// facilities maps facility names to their syslog codes
+var facilities = map[string]int{
+	"kern":     0,
+	"user":     1,
+	"mail":     2,
+	"daemon":   3,
+	"auth":     4,
+	"syslog":   5,
+	"lpr":      6,
+	"news":     7,
+	"uucp":     8,
+	"cron":     9,
+	"authpriv": 10,
+	"ftp":      11,
+	"local0":   16,
+	"local1":   17,
+	"local2":   18,
+	"local3":   19,
+	"local4":   20,
+	"local5":   21,
+	"local6":   22,
+	"local7":   23,
+}
+
+// localSockets are the unix sockets tried if no address is set, /dev/log is also served by journald
+var localSockets = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+type syslog struct {
+	network  string
+	address  string
+	facility int
+	appName  string
+	hostname string
+	tls      *tls.Config
+
+	logger hclog.Logger
+}
+
+func (s *syslog) WithConfig(config *config.Config) (common.INotifier, error) {
+	conf := config.Services.Syslog
+
+	facility, ok := facilities[strings.ToLower(conf.Facility)]
+	if !ok {
+		return nil, fmt.Errorf("unknown syslog facility %q", conf.Facility)
+	}
+
+	switch conf.Network {
+	case "", "unix", "unixgram", "udp", "tcp", "tls":
+	default:
+		return nil, fmt.Errorf("unsupported syslog network %q", conf.Network)
+	}
+
+	if conf.Network != "" && conf.Address == "" {
+		return nil, errors.New("address for syslog not defined")
+	}
+
+	s.network = conf.Network
+	s.address = conf.Address
+	s.facility = facility
+	s.appName = conf.AppName
+	s.hostname = conf.Hostname
+	s.logger = config.Logger.Named("syslog")
+
+	if s.appName == "" {
+		s.appName = nilValue
+	}
+
+	if s.hostname == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			hostname = nilValue
+		}
+		s.hostname = hostname
+	}
+
+	if s.network == "tls" {
+		s.tls = &tls.Config{InsecureSkipVerify: conf.InsecureSkipVerify}
+
+		if conf.CAFile != "" {
+			caCert, err := ioutil.ReadFile(conf.CAFile)
+			if err != nil {
+				return nil, fmt.Errorf("could not read syslog CA file: %w", err)
+			}
+
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(caCert) {
+				return nil, errors.New("could not parse syslog CA file")
+			}
+			s.tls.RootCAs = pool
+		}
+	}
+
+	s.logger.Debug("syslog config successfully initialized")
+	return s, nil
+}
+
+func (s syslog) Send(event common.Event) error {
+	s.logger.Debug("Send function")
+
+	conn, err := s.dial()
+	if err != nil {
+		return fmt.Errorf("could not connect to syslog: %w", err)
+	}
+	defer func(conn net.Conn) {
+		_ = conn.Close()
+	}(conn)
+
+	// one message is written per endpoint as an SD-ID can appear only once in a message
+	for _, ep := range event.Affected() {
+		msg := s.format(event, ep)
+
+		if err := s.write(conn, msg); err != nil {
+			return err
+		}
+	}
+
+	if event.Suppressed > 0 {
+		msg := s.message(event, severityNotice, fmt.Sprintf(`[%s event="%s" suppressed="%d"]`, sdID, escape(event.ID), event.Suppressed), event.SuppressedText())
+		if err := s.write(conn, msg); err != nil {
+			return err
+		}
+	}
+
+	s.logger.Info("syslog notification successfully sent", "network", s.network, "address", s.address)
+	return nil
+}
+
+func (s syslog) SendMockup() error {
+	fmt.Println("Sending...")
+
+	return nil
+}
+
+func NotifierFactory() common.INotifier {
+	return &syslog{}
+}
+
+// dial connects to the configured syslog server, or to the local syslog socket if no address is set
+func (s syslog) dial() (net.Conn, error) {
+	switch s.network {
+	case "":
+		var lastErr error
+		for _, socket := range localSockets {
+			for _, network := range []string{"unixgram", "unix"} {
+				conn, err := net.DialTimeout(network, socket, dialTimeout)
+				if err == nil {
+					return conn, nil
+				}
+				lastErr = err
+			}
+		}
+
+		return nil, fmt.Errorf("no local syslog socket available: %w", lastErr)
+	case "tls":
+		return tls.DialWithDialer(&net.Dialer{Timeout: dialTimeout}, "tcp", s.address, s.tls)
+	default:
+		return net.DialTimeout(s.network, s.address, dialTimeout)
+	}
+}
+
+// write writes a single message to the connection
+func (s syslog) write(conn net.Conn, msg string) error {
+	// stream transports need the message length as a frame delimiter ( RFC 6587 octet counting )
+	if s.network == "tcp" || s.network == "tls" {
+		msg = fmt.Sprintf("%d %s", len(msg), msg)
+	}
+
+	if _, err := conn.Write([]byte(msg)); err != nil {
+		return fmt.Errorf("could not write syslog message: %w", err)
+	}
+
+	return nil
+}
+
+// format creates an RFC 5424 message for a single endpoint
+func (s syslog) format(event common.Event, ep common.Endpoint) string {
+	sd := fmt.Sprintf(
+		`[%s event="%s" monitor="%s" url="%s" status="%s" severity="%s"]`,
+		sdID,
+		escape(event.ID),
+		escape(ep.Name),
+		escape(ep.Url),
+		escape(string(ep.Status)),
+		escape(string(ep.Severity)),
+	)
+
+	msg := fmt.Sprintf("%s is %s", ep.Name, ep.Status)
+	if ep.Error != "" {
+		msg += ": " + ep.Error
+	}
+
+	return s.message(event, level(ep), sd, msg)
+}
+
+// message assembles an RFC 5424 message from the header fields, structured-data and message text
+func (s syslog) message(event common.Event, severity int, sd, msg string) string {
+	return fmt.Sprintf(
+		"<%d>1 %s %s %s %d %s %s %s",
+		s.facility*8+severity,
+		event.Time.Format(timestampFormat),
+		header(s.hostname, 255),
+		header(s.appName, 48),
+		os.Getpid(),
+		msgID(event.Kind),
+		sd,
+		msg,
+	)
+}
+
+// msgID returns the message ID used for the event kind
+func msgID(kind common.Kind) string {
+	if kind == "" {
+		return "ALERT"
+	}
+
+	return strings.ToUpper(string(kind))
+}
+
+// level maps the endpoint severity to the syslog severity level
+func level(ep common.Endpoint) int {
+	if ep.Status == common.StatusUp {
+		return severityNotice
+	}
+
+	switch ep.Severity {
+	case common.SeverityInfo:
+		return severityInfo
+	case common.SeverityWarning:
+		return severityWarning
+	default:
+		return severityCritical
+	}
+}
+
+// header makes the value a valid header field, which is printable ASCII without spaces
+func header(value string, maxLength int) string {
+	value = strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 {
+			return -1
+		}
+		return r
+	}, value)
+
+	if value == "" {
+		return nilValue
+	}
+
+	if len(value) > maxLength {
+		return value[:maxLength]
+	}
+
+	return value
+}
+
+// escape escapes the characters that are not allowed in structured-data param values
+func escape(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`).Replace(value)
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/ZeljkoBenovic/go-notify/api"
+	"github.com/ZeljkoBenovic/go-notify/config"
+	"os"
+	"strings"
+	"time"
+)
+
+type stringList []string
+
+func (s stringList) String() string {
+	return strings.Join(s, ",")
+}
+
+func (s *stringList) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// runSilence manages the silences of a running go-notify through its api: silence add|list|remove
+func runSilence(fs *flag.FlagSet, args []string) error {
+	apiUrl := fs.String("api", envOr("GONOTIFY_API_URL", "http://localhost:8080"), "Address of the go-notify api")
+	token := fs.String("token", os.Getenv("GONOTIFY_API_TOKEN"), "Api token")
+	duration := config.Duration(time.Hour)
+	fs.Var(&duration, "duration", "Duration of the silence, as a duration like 30m or 2h, or a number of seconds")
+	url := fs.String("url", "", "Url pattern of the silenced monitors, * matches any characters")
+	by := fs.String("by", os.Getenv("USER"), "Name of the person creating the silence")
+	comment := fs.String("comment", "", "Reason for the silence")
+
+	var names, labels stringList
+	fs.Var(&names, "name", "Name pattern of the silenced monitors, can be repeated")
+	fs.Var(&labels, "label", "Label of the silenced monitors as key=value, can be repeated")
+
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		fs.Usage()
+		return fmt.Errorf("silence command not provided")
+	}
+
+	command := args[0]
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	client := api.NewClient(*apiUrl, *token)
+
+	switch command {
+	case "add":
+		if *by == "" {
+			return fmt.Errorf("name of the person creating the silence not provided, set the by flag")
+		}
+
+		match := config.Matcher{
+			Names:  names,
+			Labels: map[string]string{},
+			Url:    *url,
+		}
+
+		for _, label := range labels {
+			kv := strings.SplitN(label, "=", 2)
+			if len(kv) != 2 {
+				return fmt.Errorf("label %q must be in key=value format", label)
+			}
+			match.Labels[kv[0]] = kv[1]
+		}
+
+		created, err := client.AddSilence(api.SilenceRequest{
+			Match:     match,
+			Duration:  duration,
+			CreatedBy: *by,
+			Comment:   *comment,
+		})
+		if err != nil {
+			return fmt.Errorf("could not create silence: %w", err)
+		}
+
+		fmt.Printf("silence %s created, ends at %s\n", created.ID, created.EndsAt.Format(time.RFC3339))
+	case "list":
+		silences, err := client.ListSilences()
+		if err != nil {
+			return fmt.Errorf("could not list silences: %w", err)
+		}
+
+		for _, s := range silences {
+			fmt.Printf("%s\tends %s\tby %s\tnames=%s labels=%v url=%s\t%s\n",
+				s.ID, s.EndsAt.Format(time.RFC3339), s.CreatedBy,
+				strings.Join(s.Match.Names, ","), s.Match.Labels, s.Match.Url, s.Comment)
+		}
+	case "remove":
+		if fs.NArg() != 1 {
+			return fmt.Errorf("silence id not provided")
+		}
+
+		if err := client.RemoveSilence(fs.Arg(0)); err != nil {
+			return fmt.Errorf("could not remove silence: %w", err)
+		}
+
+		fmt.Printf("silence %s removed\n", fs.Arg(0))
+	default:
+		fs.Usage()
+		return fmt.Errorf("unknown silence command %s", command)
+	}
+
+	return nil
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+
+	return fallback
+}
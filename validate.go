@@ -0,0 +1,26 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/ZeljkoBenovic/go-notify/config"
+)
+
+// runValidate checks the configuration given by the flags and the config file, printing every problem found
+func runValidate(flags *flag.FlagSet, args []string) error {
+	problems, err := config.Validate(flags, args)
+	if err != nil {
+		return err
+	}
+
+	if len(problems) == 0 {
+		fmt.Println("configuration is valid")
+		return nil
+	}
+
+	for _, problem := range problems {
+		fmt.Println(problem.String())
+	}
+
+	return fmt.Errorf("found %d problem(s) in the configuration", len(problems))
+}